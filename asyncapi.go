@@ -0,0 +1,35 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Instantan/web/internal/asyncapi"
+	"github.com/Instantan/web/internal/openapi"
+)
+
+// AsyncApi serves an AsyncAPI 3.0 document describing the Sse and Socket
+// routes, which OpenAPI cannot describe properly
+type AsyncApi struct {
+	DocPath string
+}
+
+func (web *Web) AsyncApi(asyncApi AsyncApi) {
+	assertIsNotEmpty("AsyncApi.DocPath", asyncApi.DocPath)
+	web.asyncapi = asyncApi
+}
+
+func (asyncApi AsyncApi) serve(mux *http.ServeMux, oa openapi.OpenAPI) {
+	if asyncApi.DocPath == "" {
+		return
+	}
+	doc, err := json.Marshal(asyncapi.FromOpenApi(oa))
+	if err != nil {
+		panic(err)
+	}
+	mux.HandleFunc(http.MethodGet+" "+asyncApi.DocPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(200)
+		w.Write(doc)
+	})
+}
@@ -0,0 +1,188 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Credential is the value extracted from a request for a security scheme
+type Credential struct {
+	// Scheme is the name of the security scheme
+	Scheme string
+	// Token holds the bearer token or the api key
+	Token    string
+	Username string
+	Password string
+	// Scopes are the scopes the security requirement demands
+	Scopes []string
+}
+
+// Verifier checks a credential and returns the authenticated principal.
+// Returning ErrUnauthorized or ErrForbidden (or any error with status 401 or
+// 403) rejects the request with 401 or 403. Other errors are written like
+// those of handlers, e.g. a failing database with 500.
+type Verifier func(r *http.Request, credential Credential) (principal any, err error)
+
+var (
+	ErrUnauthorized = &Problem{Status: http.StatusUnauthorized}
+	ErrForbidden    = &Problem{Status: http.StatusForbidden}
+)
+
+type principalKey struct{}
+
+// Principal returns the principal a Verifier returned for the request
+func Principal(r *http.Request) any {
+	return r.Context().Value(principalKey{})
+}
+
+func PrincipalOf[T any](r *http.Request) (T, bool) {
+	principal, ok := Principal(r).(T)
+	return principal, ok
+}
+
+// Authenticate creates a middleware which requires one of the given schemes
+// to be satisfied. The schemes need a Verifier.
+func Authenticate(schemes ...SecurityScheme) Use {
+	declared := map[string]SecurityScheme{}
+	security := []SecurityRequirement{}
+	for _, scheme := range schemes {
+		assertIsValidSecurityScheme(scheme)
+		if scheme.Verifier == nil {
+			panic(fmt.Errorf("SecurityScheme.Verifier of %v must not be NIL", scheme.Name))
+		}
+		declared[scheme.Name] = scheme
+		security = append(security, SecurityRequirement{scheme.Name: {}})
+	}
+	return authenticate(security, declared)
+}
+
+func authenticate(security []SecurityRequirement, schemes map[string]SecurityScheme) Use {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var failure error = ErrUnauthorized
+			for _, requirement := range security {
+				var principal any
+				var err error
+				// the schemes are verified in the order of their names, the first
+				// principal is kept
+				for _, name := range slices.Sorted(maps.Keys(requirement)) {
+					var p any
+					if p, err = verify(r, schemes[name], requirement[name]); err != nil {
+						break
+					}
+					if principal == nil {
+						principal = p
+					}
+				}
+				if err == nil {
+					if principal != nil {
+						r = r.WithContext(context.WithValue(r.Context(), principalKey{}, principal))
+					}
+					next.ServeHTTP(w, r)
+					return
+				}
+				switch ProblemOf(err).StatusCode() {
+				case http.StatusUnauthorized:
+				case http.StatusForbidden:
+					failure = err
+				default:
+					// errors of the verifier itself are no authentication failure
+					WriteProblem(w, r, ProblemOf(err))
+					return
+				}
+			}
+			problem := ProblemOf(failure)
+			if problem.StatusCode() != http.StatusForbidden {
+				problem = ErrUnauthorized
+				challenges := map[string]bool{}
+				for _, requirement := range security {
+					for name := range requirement {
+						if challenge := schemes[name].challenge(); challenge != "" && !challenges[challenge] {
+							challenges[challenge] = true
+							w.Header().Add("WWW-Authenticate", challenge)
+						}
+					}
+				}
+			}
+			WriteProblem(w, r, problem)
+		})
+	}
+}
+
+func verify(r *http.Request, scheme SecurityScheme, scopes []string) (any, error) {
+	credential, ok := scheme.credential(r)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+	credential.Scopes = scopes
+	principal, err := scheme.Verifier(r, credential)
+	if err != nil {
+		return nil, err
+	}
+	return principal, nil
+}
+
+func (scheme SecurityScheme) credential(r *http.Request) (Credential, bool) {
+	credential := Credential{Scheme: scheme.Name}
+	switch scheme.Type {
+	case "apiKey":
+		values := parameterValues(r, scheme.In, scheme.ParameterName)
+		if len(values) == 0 || values[0] == "" {
+			return credential, false
+		}
+		credential.Token = values[0]
+		return credential, true
+	case "http":
+		if strings.EqualFold(scheme.Scheme, "basic") {
+			username, password, ok := r.BasicAuth()
+			credential.Username = username
+			credential.Password = password
+			return credential, ok
+		}
+		if !strings.EqualFold(scheme.Scheme, "bearer") {
+			return credential, false
+		}
+	}
+	authorization := r.Header.Get("Authorization")
+	prefix, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(prefix, "bearer") || token == "" {
+		return credential, false
+	}
+	credential.Token = token
+	return credential, true
+}
+
+func (scheme SecurityScheme) challenge() string {
+	switch {
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+		return `Basic realm="` + scheme.Name + `"`
+	case scheme.Type == "http", scheme.Type == "oauth2", scheme.Type == "openIdConnect":
+		return "Bearer"
+	}
+	return ""
+}
+
+// isEnforced reports if the schemes referenced by the requirements have a
+// Verifier, routes without any are only documented. A route mixing schemes
+// with and without Verifier panics, it would be open to the unverified ones.
+func isEnforced(security []SecurityRequirement, schemes map[string]SecurityScheme) bool {
+	verified, unverified := []string{}, []string{}
+	for _, requirement := range security {
+		for name := range requirement {
+			if schemes[name].Verifier != nil {
+				verified = append(verified, name)
+			} else {
+				unverified = append(unverified, name)
+			}
+		}
+	}
+	if len(verified) > 0 && len(unverified) > 0 {
+		slices.Sort(unverified)
+		panic(fmt.Errorf("security schemes %v have no Verifier but are required together with verified schemes", slices.Compact(unverified)))
+	}
+	return len(verified) > 0
+}
@@ -0,0 +1,138 @@
+package web_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+func TestEnforcedSchemesMustBeReadable(t *testing.T) {
+	verifier := func(r *http.Request, credential web.Credential) (any, error) { return nil, nil }
+	for scheme, allowed := range map[string]bool{"basic": true, "Bearer": true, "digest": false} {
+		func() {
+			defer func() {
+				if rejected := recover() != nil; rejected == allowed {
+					t.Errorf("expected %v to be allowed: %v", scheme, allowed)
+				}
+			}()
+			newWeb().SecurityScheme(web.SecurityScheme{Name: "auth", Type: "http", Scheme: scheme, Verifier: verifier})
+		}()
+	}
+	newWeb().SecurityScheme(web.SecurityScheme{Name: "auth", Type: "http", Scheme: "digest"})
+}
+
+func TestAuthenticationFailures(t *testing.T) {
+	w := newWeb()
+	w.SecurityScheme(web.SecurityScheme{
+		Name:   "bearerAuth",
+		Type:   "http",
+		Scheme: "bearer",
+		Verifier: func(r *http.Request, credential web.Credential) (any, error) {
+			switch credential.Token {
+			case "valid":
+				return "alice", nil
+			case "expired":
+				return nil, web.NewProblem(http.StatusUnauthorized, "token expired")
+			case "guest":
+				return nil, web.ErrForbidden
+			}
+			return nil, errors.New("session store unavailable")
+		},
+	})
+	w.Api(web.Api{
+		Method:   http.MethodGet,
+		Path:     "/me",
+		Security: []web.SecurityRequirement{{"bearerAuth": {}}},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ := web.PrincipalOf[string](r)
+			w.Write([]byte(principal))
+		}),
+	})
+
+	cases := []struct {
+		authorization string
+		status        int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer valid", http.StatusOK},
+		{"Bearer expired", http.StatusUnauthorized},
+		{"Bearer guest", http.StatusForbidden},
+		{"Bearer broken", http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if c.authorization != "" {
+			r.Header.Set("Authorization", c.authorization)
+		}
+		resp, body := serve(t, w, r)
+		if resp.StatusCode != c.status {
+			t.Errorf("%q: expected %v, got %v %v", c.authorization, c.status, resp.StatusCode, body)
+		}
+		if challenged := resp.Header.Get("WWW-Authenticate") != ""; challenged != (c.status == http.StatusUnauthorized) {
+			t.Errorf("%q: unexpected challenge %q", c.authorization, resp.Header.Get("WWW-Authenticate"))
+		}
+		if c.status == http.StatusInternalServerError && strings.Contains(body, "session store") {
+			t.Errorf("expected the internal error not to be exposed, got %v", body)
+		}
+	}
+}
+
+func TestMixedVerificationPanics(t *testing.T) {
+	w := newWeb()
+	w.SecurityScheme(web.SecurityScheme{
+		Name:     "bearer",
+		Type:     "http",
+		Scheme:   "bearer",
+		Verifier: func(r *http.Request, credential web.Credential) (any, error) { return "alice", nil },
+	})
+	w.SecurityScheme(web.SecurityScheme{Name: "oauth", Type: "openIdConnect", OpenIdConnectUrl: "https://example.com/.well-known/openid-configuration"})
+	w.Api(web.Api{
+		Method:   http.MethodGet,
+		Path:     "/secret",
+		Security: []web.SecurityRequirement{{"bearer": {}}, {"oauth": {}}},
+		Handler:  http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("secret")) }),
+	})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected the route to be refused instead of served without authentication")
+		}
+	}()
+	w.Server()
+}
+
+func TestPrincipalOfCombinedSchemes(t *testing.T) {
+	w := newWeb()
+	for _, name := range []string{"b", "a", "c"} {
+		w.SecurityScheme(web.SecurityScheme{
+			Name:          name,
+			Type:          "apiKey",
+			In:            "header",
+			ParameterName: "X-Key-" + name,
+			Verifier:      func(r *http.Request, credential web.Credential) (any, error) { return credential.Scheme, nil },
+		})
+	}
+	w.Api(web.Api{
+		Method:   http.MethodGet,
+		Path:     "/me",
+		Security: []web.SecurityRequirement{{"c": {}, "b": {}, "a": {}}},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ := web.PrincipalOf[string](r)
+			w.Write([]byte(principal))
+		}),
+	})
+
+	for range 20 {
+		r := httptest.NewRequest(http.MethodGet, "/me", nil)
+		for _, name := range []string{"a", "b", "c"} {
+			r.Header.Set("X-Key-"+name, "key")
+		}
+		if resp, body := serve(t, w, r); resp.StatusCode != http.StatusOK || body != "a" {
+			t.Fatalf("expected the principal of the first scheme by name, got %v %v", resp.StatusCode, body)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package web
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Instantan/web/internal/openapi"
+)
+
+type binder struct {
+	fields []bindField
+	body   *bindField
+}
+
+type bindField struct {
+	index       int
+	name        string
+	in          string
+	optional    bool
+	deprecated  bool
+	description string
+	validate    string
+	typ         reflect.Type
+	// schema checks the bound value, it is nil without constraints or enum
+	schema *openapi.Schema
+}
+
+type BindError struct {
+	In   string
+	Name string
+	Err  error
+}
+
+func (e *BindError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("invalid %v: %v", e.In, e.Err)
+	}
+	return fmt.Sprintf("invalid %v parameter %q: %v", e.In, e.Name, e.Err)
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+func (e *BindError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+func newBinder(t reflect.Type) *binder {
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Errorf("%v must be a struct", t))
+	}
+	b := &binder{fields: []bindField{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Name == "Body" {
+			b.body = &bindField{
+				index:       i,
+				name:        "body",
+				in:          "body",
+				optional:    field.Type.Kind() == reflect.Pointer,
+				description: field.Tag.Get("description"),
+				typ:         field.Type,
+			}
+			if schema := openapi.TypeToSchema(field.Type); schema.Constrained() {
+				b.body.schema = schema
+			}
+			continue
+		}
+		for _, in := range []string{"path", "query", "header", "cookie"} {
+			tag, ok := field.Tag.Lookup(in)
+			if !ok {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			if name == "" {
+				name = field.Name
+			}
+			bound := bindField{
+				index:       i,
+				name:        name,
+				in:          in,
+				optional:    in != "path" && options == "optional",
+				deprecated:  field.Tag.Get("deprecated") == "true",
+				description: field.Tag.Get("description"),
+				validate:    field.Tag.Get("validate"),
+				typ:         field.Type,
+			}
+			schema := openapi.TypeToSchema(field.Type)
+			if err := schema.Constrain(bound.validate); err != nil {
+				panic(fmt.Errorf("%v.%v: %w", t, field.Name, err))
+			}
+			if schema.Constrained() {
+				bound.schema = schema
+			}
+			b.fields = append(b.fields, bound)
+			break
+		}
+	}
+	return b
+}
+
+// bind binds the request to v. Values violating the constraints of their
+// validate tags are rejected with a 400 for parameters and a 422 for the body.
+func (b *binder) bind(r *http.Request, v reflect.Value) error {
+	violations := []openapi.Violation{}
+	for _, field := range b.fields {
+		values := parameterValues(r, field.in, field.name)
+		if len(values) == 0 {
+			if field.optional {
+				continue
+			}
+			return &BindError{In: field.in, Name: field.name, Err: fmt.Errorf("is required")}
+		}
+		if err := parseValues(v.Field(field.index), values); err != nil {
+			return &BindError{In: field.in, Name: field.name, Err: err}
+		}
+		if field.schema != nil {
+			violations = append(violations, validateValue(field.schema, v.Field(field.index), field.in+"."+field.name)...)
+		}
+	}
+	if len(violations) > 0 {
+		return violationsProblem(http.StatusBadRequest, violations)
+	}
+	if b.body != nil {
+		if r.Body == nil || r.Body == http.NoBody {
+			if b.body.optional {
+				return nil
+			}
+			return &BindError{In: "body", Err: fmt.Errorf("is required")}
+		}
+		if err := codecsOf(r).decode(r, v.Field(b.body.index).Addr().Interface()); err != nil {
+			return err
+		}
+		if b.body.schema != nil {
+			if violations := validateValue(b.body.schema, v.Field(b.body.index), "body"); len(violations) > 0 {
+				return violationsProblem(http.StatusUnprocessableEntity, violations)
+			}
+		}
+	}
+	return nil
+}
+
+// validateValue checks the bound value against the schema by its JSON
+// encoding, the schema describes the JSON encoding regardless of the codec.
+// Omitted pointer, slice and map fields are encoded as null, which their
+// schema accepts unless they are validate:"required".
+func validateValue(schema *openapi.Schema, v reflect.Value, location string) []openapi.Violation {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil
+	}
+	encoded, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil
+	}
+	return schema.Validate(value, nil, location)
+}
+
+func parameterValues(r *http.Request, in string, name string) []string {
+	switch in {
+	case "path":
+		if value := r.PathValue(name); value != "" {
+			return []string{value}
+		}
+	case "query":
+		return r.URL.Query()[name]
+	case "header":
+		return r.Header.Values(name)
+	case "cookie":
+		if cookie, err := r.Cookie(name); err == nil {
+			return []string{cookie.Value}
+		}
+	}
+	return nil
+}
+
+func parseValues(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+		return parseValues(v.Elem(), values)
+	}
+	if v.Kind() == reflect.Slice && !v.Addr().Type().Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i := range values {
+			if err := parseValue(slice.Index(i), values[i]); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return parseValue(v, values[0])
+}
+
+func parseValue(v reflect.Value, value string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an unsigned integer", value)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		v.SetFloat(f)
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		return parseValue(v.Elem(), value)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
+
+// exampleOf returns the zero value of t, dereferencing pointers so the
+// generated schema describes the pointed to type instead of null.
+func exampleOf(t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return reflect.Zero(t).Interface()
+}
@@ -0,0 +1,178 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Instantan/web/internal/openapi"
+)
+
+// Callback is a request the Web sends to an url the client passed with the
+// request of an Api, e.g. for subscriptions. Declared in Api.Callbacks it is
+// documented in the spec, Dispatch delivers it.
+//
+// As the client chooses the url, callbacks can be abused to reach hosts only
+// the server can reach (SSRF). Urls have to use http or https and the default
+// client only connects to public addresses, Allow restricts them further.
+type Callback struct {
+	// Expression is the runtime expression resolving to the url, e.g.
+	// {$request.body#/callbackUrl} or {$request.query.url}/events
+	Expression  string
+	Method      string
+	Summary     string
+	Description string
+	// Payload is an example of the JSON body sent to the url
+	Payload any
+	// Responses are the responses expected from the client, it defaults to
+	// an empty 200
+	Responses Responses
+	// Retry configures the redelivery of failed requests
+	Retry Retry
+	// Allow is called with every url before it is sent to, e.g. to check the
+	// host against an allow list. An error rejects the url.
+	Allow func(u *url.URL) error
+	// Client sends the requests, it defaults to a client with a 10 second
+	// timeout which refuses to connect to loopback, private and link-local
+	// addresses and ignores HTTP_PROXY. A custom client has to protect those
+	// itself.
+	Client *http.Client
+}
+
+var defaultCallbackClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		// no proxy, its address would be checked instead of the one of the
+		// callback
+		Proxy:               nil,
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: dialPublicOnly}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+// dialPublicOnly refuses connections to addresses which are not public, it
+// runs after the name was resolved so DNS can't redirect to them either
+func dialPublicOnly(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if addr = addr.Unmap(); !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return fmt.Errorf("callback: %v is not a public address", addr)
+	}
+	return nil
+}
+
+// Url resolves the Expression against the request. Body is the decoded
+// request body, it is encoded as JSON to resolve $request.body pointers.
+func (callback Callback) Url(r *http.Request, body any) (string, error) {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := openapi.ResolveExpression(callback.Expression, openapi.RuntimeContext{
+		Request:     r,
+		RequestBody: requestBody,
+	})
+	if err != nil {
+		return "", fmt.Errorf("callback url %v: %w", callback.Expression, err)
+	}
+	if err := callback.check(resolved); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// check rejects urls without http or https scheme and those not allowed
+func (callback Callback) check(rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return fmt.Errorf("callback url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("callback url %q must be an absolute http or https url", rawUrl)
+	}
+	if callback.Allow != nil {
+		if err := callback.Allow(u); err != nil {
+			return fmt.Errorf("callback url %q is not allowed: %w", rawUrl, err)
+		}
+	}
+	return nil
+}
+
+// Send delivers the payload as JSON to the url and retries failed deliveries
+// like Webhook.Send
+func (callback Callback) Send(ctx context.Context, url string, payload any) error {
+	if err := callback.check(url); err != nil {
+		return err
+	}
+	return callback.webhook("callback").Send(ctx, url, nil, payload)
+}
+
+// Dispatch resolves the url against the request and delivers the payload in
+// the background, so the handler can respond first. Failed deliveries are
+// logged, use Url and Send to handle them.
+func (callback Callback) Dispatch(r *http.Request, body any, payload any) error {
+	url, err := callback.Url(r, body)
+	if err != nil {
+		return err
+	}
+	ctx := context.WithoutCancel(r.Context())
+	go func() {
+		if err := callback.Send(ctx, url, payload); err != nil {
+			log.Printf("web: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (callback Callback) webhook(name string) Webhook {
+	client := callback.Client
+	if client == nil {
+		client = defaultCallbackClient
+	}
+	return Webhook{
+		Name:        name,
+		Method:      callback.Method,
+		Summary:     callback.Summary,
+		Description: callback.Description,
+		Payload:     callback.Payload,
+		Responses:   callback.Responses,
+		Retry:       callback.Retry,
+		Client:      client,
+	}
+}
+
+func assertIsValidCallback(name string, callback Callback) {
+	assertIsNotEmpty("Callback.Expression of "+name, callback.Expression)
+	assertIsNotNil("Callback.Payload of "+name, callback.Payload)
+	if callback.Method != "" {
+		assertIsOneOf(strings.ToUpper(callback.Method), []string{http.MethodPost, http.MethodPut, http.MethodPatch})
+	}
+}
+
+// openapiCallbacks documents the callbacks keyed by their names
+func openapiCallbacks(callbacks map[string]Callback, components *openapi.Components) map[string]openapi.Callback {
+	if len(callbacks) == 0 {
+		return nil
+	}
+	result := map[string]openapi.Callback{}
+	for name, callback := range callbacks {
+		result[name] = openapi.Callback{
+			callback.Expression: callback.webhook(name).openapiPathItem(components, false),
+		}
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package web_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+func TestCallbacksIgnoreProxies(t *testing.T) {
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+	}))
+	defer proxy.Close()
+	t.Setenv("HTTP_PROXY", proxy.URL)
+
+	callback := web.Callback{Expression: "{$request.query.url}", Payload: item{}, Retry: web.Retry{Attempts: 1}}
+	if err := callback.Send(context.Background(), "http://10.0.0.1/hook", item{}); err == nil || !strings.Contains(err.Error(), "10.0.0.1 is not a public address") {
+		t.Errorf("expected the private target to be refused, got %v", err)
+	}
+	select {
+	case url := <-proxied:
+		t.Errorf("expected the callback not to be sent through the proxy, got %v", url)
+	default:
+	}
+}
+
+func TestCallbackUrlsAreChecked(t *testing.T) {
+	callback := web.Callback{
+		Expression: "{$request.query.url}",
+		Payload:    item{},
+		Allow: func(u *url.URL) error {
+			if u.Hostname() != "example.com" {
+				return errors.New("unknown host")
+			}
+			return nil
+		},
+	}
+	for rawUrl, allowed := range map[string]bool{
+		"https://example.com/events":    true,
+		"http://example.com/events":     true,
+		"https://internal.local/events": false,
+		"file:///etc/passwd":            false,
+		"gopher://example.com":          false,
+		"/relative":                     false,
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/subscriptions?url="+url.QueryEscape(rawUrl), nil)
+		if _, err := callback.Url(r, nil); (err == nil) != allowed {
+			t.Errorf("expected %v to be allowed=%v, got %v", rawUrl, allowed, err)
+		}
+	}
+}
+
+func TestCallbacksRefuseInternalAddresses(t *testing.T) {
+	received := make(chan string, 1)
+	client := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	}))
+	defer client.Close()
+
+	callback := web.Callback{Expression: "{$request.query.url}", Payload: item{}, Retry: web.Retry{Attempts: 1}}
+	if err := callback.Send(context.Background(), client.URL, item{}); err == nil || !strings.Contains(err.Error(), "not a public address") {
+		t.Errorf("expected the loopback address to be refused, got %v", err)
+	}
+
+	callback.Client = client.Client()
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions?url="+url.QueryEscape(client.URL), nil)
+	if err := callback.Dispatch(r, nil, item{Name: "a"}); err != nil {
+		t.Fatalf("expected the callback to be dispatched, got %v", err)
+	}
+	if body := <-received; body != `{"name":"a"}` {
+		t.Errorf("unexpected payload %v", body)
+	}
+}
@@ -0,0 +1,476 @@
+package web
+
+import (
+	"context"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Codec encodes and decodes the bodies of one media type. A Codec can
+// implement Supports(reflect.Type) bool to restrict the types it is used for.
+type Codec interface {
+	Encode(w io.Writer, value any) error
+	Decode(r io.Reader, value any) error
+}
+
+type supporter interface {
+	Supports(t reflect.Type) bool
+}
+
+// JsonCodec encodes and decodes application/json
+type JsonCodec struct{}
+
+func (JsonCodec) Encode(w io.Writer, value any) error {
+	return json.NewEncoder(w).Encode(value)
+}
+
+func (JsonCodec) Decode(r io.Reader, value any) error {
+	return json.NewDecoder(r).Decode(value)
+}
+
+// XmlCodec encodes and decodes application/xml
+type XmlCodec struct{}
+
+func (XmlCodec) Encode(w io.Writer, value any) error {
+	return xml.NewEncoder(w).Encode(value)
+}
+
+func (XmlCodec) Decode(r io.Reader, value any) error {
+	return xml.NewDecoder(r).Decode(value)
+}
+
+func (XmlCodec) Supports(t reflect.Type) bool {
+	return indirect(t).Kind() != reflect.Map
+}
+
+// FormCodec encodes and decodes application/x-www-form-urlencoded. Struct
+// fields are named by their form tag.
+type FormCodec struct{}
+
+func (FormCodec) Encode(w io.Writer, value any) error {
+	values := url.Values{}
+	v := reflect.Indirect(reflect.ValueOf(value))
+	switch {
+	case v.Kind() == reflect.Struct:
+		for i, name := range fieldNames(v.Type(), "form") {
+			if name == "" {
+				continue
+			}
+			field := v.Field(i)
+			if field.Kind() == reflect.Slice && !isText(field.Type()) {
+				for j := 0; j < field.Len(); j++ {
+					s, err := formatValue(field.Index(j))
+					if err != nil {
+						return err
+					}
+					values.Add(name, s)
+				}
+				continue
+			}
+			s, err := formatValue(field)
+			if err != nil {
+				return err
+			}
+			values.Set(name, s)
+		}
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		for _, key := range v.MapKeys() {
+			value := v.MapIndex(key)
+			if value.Kind() == reflect.Slice {
+				for j := 0; j < value.Len(); j++ {
+					values.Add(key.String(), fmt.Sprint(value.Index(j).Interface()))
+				}
+				continue
+			}
+			values.Set(key.String(), fmt.Sprint(value.Interface()))
+		}
+	default:
+		return fmt.Errorf("%v can not be encoded as form", v.Type())
+	}
+	_, err := io.WriteString(w, values.Encode())
+	return err
+}
+
+func (FormCodec) Decode(r io.Reader, value any) error {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+	v := reflect.ValueOf(value).Elem()
+	switch {
+	case v.Kind() == reflect.Struct:
+		for i, name := range fieldNames(v.Type(), "form") {
+			if name == "" || len(values[name]) == 0 {
+				continue
+			}
+			if err := parseValues(v.Field(i), values[name]); err != nil {
+				return fmt.Errorf("field %q: %w", name, err)
+			}
+		}
+	case v.Type() == reflect.TypeFor[url.Values]():
+		v.Set(reflect.ValueOf(values))
+	case v.Type() == reflect.TypeFor[map[string]string]():
+		m := map[string]string{}
+		for key := range values {
+			m[key] = values.Get(key)
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("%v can not be decoded from form", v.Type())
+	}
+	return nil
+}
+
+func (FormCodec) Supports(t reflect.Type) bool {
+	t = indirect(t)
+	return t.Kind() == reflect.Struct || t == reflect.TypeFor[url.Values]() || t == reflect.TypeFor[map[string]string]()
+}
+
+// CsvCodec encodes and decodes text/csv from a [][]string or a slice of
+// structs, whose fields are named by their csv tag in the header row.
+type CsvCodec struct{}
+
+func (CsvCodec) Encode(w io.Writer, value any) error {
+	if records, ok := value.([][]string); ok {
+		return csv.NewWriter(w).WriteAll(records)
+	}
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if v.Kind() != reflect.Slice || indirect(v.Type().Elem()).Kind() != reflect.Struct {
+		return fmt.Errorf("%v can not be encoded as csv", v.Type())
+	}
+	names := fieldNames(indirect(v.Type().Elem()), "csv")
+	records := [][]string{{}}
+	for _, name := range names {
+		if name != "" {
+			records[0] = append(records[0], name)
+		}
+	}
+	for i := 0; i < v.Len(); i++ {
+		row := reflect.Indirect(v.Index(i))
+		record := []string{}
+		for j, name := range names {
+			if name == "" {
+				continue
+			}
+			s, err := formatValue(row.Field(j))
+			if err != nil {
+				return err
+			}
+			record = append(record, s)
+		}
+		records = append(records, record)
+	}
+	return csv.NewWriter(w).WriteAll(records)
+}
+
+func (CsvCodec) Decode(r io.Reader, value any) error {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return err
+	}
+	if p, ok := value.(*[][]string); ok {
+		*p = records
+		return nil
+	}
+	v := reflect.ValueOf(value).Elem()
+	if v.Kind() != reflect.Slice || indirect(v.Type().Elem()).Kind() != reflect.Struct {
+		return fmt.Errorf("%v can not be decoded from csv", v.Type())
+	}
+	slice := reflect.MakeSlice(v.Type(), 0, max(len(records)-1, 0))
+	if len(records) > 0 {
+		names := fieldNames(indirect(v.Type().Elem()), "csv")
+		for line, record := range records[1:] {
+			row := reflect.New(v.Type().Elem()).Elem()
+			for column, header := range records[0] {
+				i := slices.Index(names, header)
+				if i < 0 || column >= len(record) {
+					continue
+				}
+				if err := parseValue(reflect.Indirect(allocate(row)).Field(i), record[column]); err != nil {
+					return fmt.Errorf("line %v column %q: %w", line+2, header, err)
+				}
+			}
+			slice = reflect.Append(slice, row)
+		}
+	}
+	v.Set(slice)
+	return nil
+}
+
+func (CsvCodec) Supports(t reflect.Type) bool {
+	t = indirect(t)
+	return t == reflect.TypeFor[[][]string]() || t.Kind() == reflect.Slice && indirect(t.Elem()).Kind() == reflect.Struct
+}
+
+// TextCodec encodes and decodes text/plain from strings, byte slices,
+// numbers, booleans and encoding.TextMarshaler implementations
+type TextCodec struct{}
+
+func (TextCodec) Encode(w io.Writer, value any) error {
+	if b, ok := value.([]byte); ok {
+		_, err := w.Write(b)
+		return err
+	}
+	s, err := formatValue(reflect.ValueOf(value))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, s)
+	return err
+}
+
+func (TextCodec) Decode(r io.Reader, value any) error {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if p, ok := value.(*[]byte); ok {
+		*p = body
+		return nil
+	}
+	return parseValue(reflect.ValueOf(value).Elem(), string(body))
+}
+
+func (TextCodec) Supports(t reflect.Type) bool {
+	t = indirect(t)
+	if t == reflect.TypeFor[[]byte]() || isText(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// builtinCodecs are available for every explicitly declared ContentType
+// variant, even if they are not registered
+var builtinCodecs = map[string]Codec{
+	"application/json":                  JsonCodec{},
+	"application/xml":                   XmlCodec{},
+	"application/x-www-form-urlencoded": FormCodec{},
+	"text/csv":                          CsvCodec{},
+	"text/plain":                        TextCodec{},
+	"text/html":                         TextCodec{},
+}
+
+// codecs is the registry of a Web, typed handlers offer every registered
+// codec which supports their types
+type codecs struct {
+	mediaTypes []string
+	codecs     map[string]Codec
+	multipart  Multipart
+}
+
+func newCodecs() *codecs {
+	c := &codecs{codecs: map[string]Codec{}, multipart: defaultMultipart}
+	c.register("application/json", JsonCodec{})
+	return c
+}
+
+func (c *codecs) register(mediaType string, codec Codec) {
+	if _, ok := c.codecs[mediaType]; !ok {
+		c.mediaTypes = append(c.mediaTypes, mediaType)
+	}
+	c.codecs[mediaType] = codec
+}
+
+func (c *codecs) lookup(mediaType string) (Codec, bool) {
+	if codec, ok := c.codecs[mediaType]; ok {
+		return codec, true
+	}
+	codec, ok := builtinCodecs[mediaType]
+	return codec, ok
+}
+
+// supporting returns the registered media types whose codec supports t,
+// structs with File fields are only supported as multipart/form-data
+func (c *codecs) supporting(t reflect.Type) []string {
+	if t != nil && hasFiles(t) {
+		return []string{"multipart/form-data"}
+	}
+	mediaTypes := []string{}
+	for _, mediaType := range c.mediaTypes {
+		if s, ok := c.codecs[mediaType].(supporter); ok && t != nil && !s.Supports(t) {
+			continue
+		}
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	return mediaTypes
+}
+
+// decode decodes the body by its Content-Type with the codec of the media
+// type. Only the declared ContentType variants of the route or the media
+// types supporting the value are accepted, the first one is assumed if the
+// Content-Type is missing.
+func (c *codecs) decode(r *http.Request, value any) error {
+	supported, ok := r.Context().Value(consumesKey{}).([]string)
+	if !ok {
+		supported = c.supporting(reflect.TypeOf(value).Elem())
+	}
+	mediaType := "application/json"
+	if len(supported) > 0 {
+		mediaType = supported[0]
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, _ = mime.ParseMediaType(contentType)
+	}
+	unsupported := NewProblem(http.StatusUnsupportedMediaType, fmt.Sprintf("%q is not a supported content type", mediaType)).With("supported", supported)
+	if !slices.Contains(supported, mediaType) {
+		return unsupported
+	}
+	if mediaType == "multipart/form-data" && indirect(reflect.TypeOf(value)).Kind() == reflect.Struct {
+		return decodeMultipart(r, value, c.multipart)
+	}
+	codec, ok := c.lookup(mediaType)
+	if !ok {
+		p, ok := value.(*[]byte)
+		if !ok {
+			return unsupported
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return &BindError{In: "body", Err: err}
+		}
+		*p = body
+		return nil
+	}
+	if err := codec.Decode(r.Body, value); err != nil {
+		return &BindError{In: "body", Err: err}
+	}
+	return nil
+}
+
+// isBinary reports if the value is documented as binary for the media type,
+// which is the case for byte slices outside of JSON and media types without
+// codec. Event and NDJSON streams are documented by the schema of their
+// items.
+func (c *codecs) isBinary(mediaType string, value any) bool {
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || mediaType == "text/event-stream" || mediaType == "application/x-ndjson" {
+		return false
+	}
+	if _, ok := value.([]byte); ok {
+		return true
+	}
+	_, ok := c.lookup(mediaType)
+	return !ok
+}
+
+type codecsKey struct{}
+
+var defaultCodecs = newCodecs()
+
+// withCodecs makes the codecs of the Web available to the handlers of a route
+func withCodecs(c *codecs) Use {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), codecsKey{}, c)))
+		})
+	}
+}
+
+func codecsOf(r *http.Request) *codecs {
+	if c, ok := r.Context().Value(codecsKey{}).(*codecs); ok {
+		return c
+	}
+	return defaultCodecs
+}
+
+func assertIsValidCodec(mediaType string, codec Codec) {
+	assertIsNotEmpty("Codec.mediaType", mediaType)
+	if _, _, err := mime.ParseMediaType(mediaType); err != nil {
+		panic(fmt.Errorf("Codec.mediaType %v is not a media type: %w", mediaType, err))
+	}
+	if codec == nil {
+		panic(fmt.Errorf("Codec of %v must not be NIL", mediaType))
+	}
+}
+
+// fieldNames returns the names of the fields of t by the tag, unexported and
+// skipped fields have an empty name
+func fieldNames(t reflect.Type, tag string) []string {
+	names := make([]string, t.NumField())
+	for i := range names {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		switch name {
+		case "-":
+		case "":
+			names[i] = field.Name
+		default:
+			names[i] = name
+		}
+	}
+	return names
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+func isText(t reflect.Type) bool {
+	return t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+}
+
+func formatValue(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "", nil
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %v", v.Type())
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// allocate allocates nil pointers of v and returns it
+func allocate(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		v.Set(reflect.New(v.Type().Elem()))
+	}
+	return v
+}
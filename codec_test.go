@@ -0,0 +1,47 @@
+package web_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+func TestRegisteredCodecs(t *testing.T) {
+	w := newWeb()
+	w.Codec("application/xml", web.XmlCodec{})
+	w.Api(web.Api{
+		Method:  http.MethodPost,
+		Path:    "/items",
+		Handler: web.Handle(func(r *http.Request, in struct{ Body item }) (item, error) { return in.Body, nil }),
+	})
+
+	operation := lookup(spec(t, w), "paths", "/items", "post")
+	for _, contentType := range []string{"application/json", "application/xml"} {
+		if lookup(operation, "requestBody", "content", contentType) == nil || lookup(operation, "responses", "200", "content", contentType) == nil {
+			t.Errorf("expected %v to be documented, got %v", contentType, operation)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`<item><Name>a</Name></item>`))
+	r.Header.Set("Content-Type", "application/xml")
+	r.Header.Set("Accept", "application/json")
+	if resp, body := serve(t, w, r); resp.StatusCode != http.StatusOK || strings.TrimSpace(body) != `{"name":"a"}` {
+		t.Errorf("expected the xml body to be decoded, got %v %v", resp.StatusCode, body)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name":"a"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "application/xml")
+	if resp, body := serve(t, w, r); resp.StatusCode != http.StatusOK || !strings.Contains(body, "<Name>a</Name>") {
+		t.Errorf("expected the response to be encoded as xml, got %v %v", resp.StatusCode, body)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`name=a`))
+	r.Header.Set("Content-Type", "text/csv")
+	if resp, body := serve(t, w, r); resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("expected a body without codec to be rejected, got %v %v", resp.StatusCode, body)
+	}
+}
@@ -1,5 +1,13 @@
 package web
 
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+)
+
 type ContentType struct {
 	ApplicationJson               any
 	ApplicationXml                any
@@ -7,12 +15,14 @@ type ContentType struct {
 	ApplicationJavaScript         any
 	ApplicationPdf                any
 	ApplicationZip                any
+	ApplicationXNdjson            any
 
-	TextHtml       any
-	TextPlain      any
-	TextCss        any
-	TextCsv        any
-	TextJavaScript any
+	TextHtml        any
+	TextPlain       any
+	TextCss         any
+	TextCsv         any
+	TextJavaScript  any
+	TextEventStream any
 
 	ImageJpeg   any
 	ImagePng    any
@@ -63,6 +73,11 @@ func (c *ContentType) Iterate() func(func(contentType string, value any) bool) {
 				return
 			}
 		}
+		if c.ApplicationXNdjson != nil {
+			if !yield("application/x-ndjson", c.ApplicationXNdjson) {
+				return
+			}
+		}
 
 		if c.TextHtml != nil {
 			if !yield("text/html", c.TextHtml) {
@@ -89,6 +104,11 @@ func (c *ContentType) Iterate() func(func(contentType string, value any) bool) {
 				return
 			}
 		}
+		if c.TextEventStream != nil {
+			if !yield("text/event-stream", c.TextEventStream) {
+				return
+			}
+		}
 
 		if c.ImageJpeg != nil {
 			if !yield("image/jpeg", c.ImageJpeg) {
@@ -169,3 +189,22 @@ func isContentType(t any) *ContentType {
 		return nil
 	}
 }
+
+type consumesKey struct{}
+
+// consumesContentTypes rejects request bodies whose content type is not one of
+// the declared ContentType variants with 415
+func consumesContentTypes(mediaTypes []string) Use {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if contentType := r.Header.Get("Content-Type"); contentType != "" {
+				mediaType, _, _ := mime.ParseMediaType(contentType)
+				if !slices.Contains(mediaTypes, mediaType) {
+					WriteProblem(w, r, NewProblem(http.StatusUnsupportedMediaType, fmt.Sprintf("%q is not a supported content type", mediaType)).With("supported", mediaTypes))
+					return
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), consumesKey{}, mediaTypes)))
+		})
+	}
+}
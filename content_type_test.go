@@ -0,0 +1,52 @@
+package web_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+type signup struct {
+	Email string `json:"email" form:"email"`
+}
+
+func TestBodyContentTypes(t *testing.T) {
+	w := newWeb()
+	w.Codec("application/x-www-form-urlencoded", web.FormCodec{})
+	w.Api(web.Api{
+		Method: http.MethodPost,
+		Path:   "/signups",
+		Parameter: web.Parameter{
+			Body: web.Body{Value: web.ContentType{ApplicationJson: signup{}, ApplicationXWWWFormURLEncoded: signup{}}},
+		},
+		Handler: web.Handle(func(r *http.Request, in struct{ Body signup }) (string, error) { return in.Body.Email, nil }),
+	})
+
+	content, _ := lookup(spec(t, w), "paths", "/signups", "post", "requestBody", "content").(map[string]any)
+	if len(content) != 2 || content["application/x-www-form-urlencoded"] == nil {
+		t.Errorf("expected both variants to be documented, got %v", content)
+	}
+	cases := []struct {
+		contentType string
+		body        string
+		status      int
+	}{
+		{"application/json", `{"email":"a@example.com"}`, http.StatusOK},
+		{"application/x-www-form-urlencoded", `email=a%40example.com`, http.StatusOK},
+		{"text/plain", `a@example.com`, http.StatusUnsupportedMediaType},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodPost, "/signups", strings.NewReader(c.body))
+		r.Header.Set("Content-Type", c.contentType)
+		resp, body := serve(t, w, r)
+		if resp.StatusCode != c.status {
+			t.Errorf("%v: expected %v, got %v %v", c.contentType, c.status, resp.StatusCode, body)
+		}
+		if c.status == http.StatusOK && !strings.Contains(body, "a@example.com") {
+			t.Errorf("%v: expected the body to be decoded, got %v", c.contentType, body)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (api Api) isDeprecated() bool {
+	return api.Deprecated || !api.DeprecatedAt.IsZero() || !api.Sunset.IsZero()
+}
+
+func (api Api) deprecationDescription() string {
+	if !api.isDeprecated() {
+		return api.Description
+	}
+	note := "Deprecated"
+	if !api.DeprecatedAt.IsZero() {
+		note += " since " + api.DeprecatedAt.UTC().Format(time.DateOnly)
+	}
+	if !api.Sunset.IsZero() {
+		note += ", will be removed after " + api.Sunset.UTC().Format(time.DateOnly)
+	}
+	notes := []string{note + "."}
+	if api.Replacement != "" {
+		notes = append(notes, "Use "+api.Replacement+" instead.")
+	}
+	if api.Description == "" {
+		return strings.Join(notes, " ")
+	}
+	return api.Description + "\n\n" + strings.Join(notes, " ")
+}
+
+// withVersionDeprecation takes over the deprecation of the version the api
+// belongs to, the dates of the api itself take precedence
+func (api Api) withVersionDeprecation(versions []Version, name string) Api {
+	for _, version := range versions {
+		if version.Name != name || !version.isDeprecated() {
+			continue
+		}
+		api.Deprecated = true
+		if api.DeprecatedAt.IsZero() {
+			api.DeprecatedAt = version.DeprecatedAt
+		}
+		if api.Sunset.IsZero() {
+			api.Sunset = version.Sunset
+		}
+	}
+	return api
+}
+
+// deprecation announces the deprecation with the Deprecation (RFC 9745),
+// Sunset (RFC 8594) and Link headers. The Deprecation header carries a date,
+// it is left out as long as the api has no DeprecatedAt.
+func deprecation(api Api) Use {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !api.DeprecatedAt.IsZero() {
+				w.Header().Set("Deprecation", "@"+strconv.FormatInt(api.DeprecatedAt.Unix(), 10))
+			}
+			if !api.Sunset.IsZero() {
+				w.Header().Set("Sunset", api.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if strings.HasPrefix(api.Replacement, "/") || strings.Contains(api.Replacement, "://") {
+				w.Header().Add("Link", "<"+api.Replacement+`>; rel="successor-version"`)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package web_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Instantan/web"
+)
+
+func TestDeprecationHeaders(t *testing.T) {
+	w := newWeb()
+	w.Api(web.Api{
+		Method:       http.MethodGet,
+		Path:         "/users",
+		DeprecatedAt: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:       time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		Replacement:  "/v2/users",
+		Handler:      http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	})
+	w.Api(web.Api{
+		Method:     http.MethodGet,
+		Path:       "/groups",
+		Deprecated: true,
+		Handler:    http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	})
+	w.Api(web.Api{
+		Method:  http.MethodGet,
+		Path:    "/roles",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	})
+
+	resp, _ := serve(t, w, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if resp.Header.Get("Deprecation") != "@1780272000" || resp.Header.Get("Sunset") != "Fri, 01 Jan 2027 00:00:00 GMT" {
+		t.Errorf("expected the Deprecation and Sunset headers, got %v", resp.Header)
+	}
+	if link := resp.Header.Get("Link"); link != `</v2/users>; rel="successor-version"` {
+		t.Errorf("expected a link to the replacement, got %q", link)
+	}
+	resp, _ = serve(t, w, httptest.NewRequest(http.MethodGet, "/groups", nil))
+	if resp.Header.Get("Deprecation") != "" || resp.Header.Get("Sunset") != "" || resp.Header.Get("Link") != "" {
+		t.Errorf("expected no headers without dates, got %v", resp.Header)
+	}
+	resp, _ = serve(t, w, httptest.NewRequest(http.MethodGet, "/roles", nil))
+	if resp.Header.Get("Deprecation") != "" {
+		t.Errorf("expected no Deprecation header, got %v", resp.Header)
+	}
+
+	doc := spec(t, w)
+	if lookup(doc, "paths", "/users", "get", "deprecated") != true || lookup(doc, "paths", "/groups", "get", "deprecated") != true {
+		t.Error("expected the operations to be documented as deprecated")
+	}
+	description, _ := lookup(doc, "paths", "/users", "get", "description").(string)
+	if description != "Deprecated since 2026-06-01, will be removed after 2027-01-01. Use /v2/users instead." {
+		t.Errorf("expected the sunset and replacement to be described, got %q", description)
+	}
+	if lookup(doc, "paths", "/roles", "get", "deprecated") == true {
+		t.Error("expected /roles not to be deprecated")
+	}
+}
+
+func TestVersionDeprecationHeaders(t *testing.T) {
+	w := newWeb()
+	w.Version(web.Version{
+		Name:         "v1",
+		DeprecatedAt: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:       time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		OpenApi:      web.OpenApi{DocPath: "/v1/doc.json", UiPath: "/v1/doc", UiVariant: "scalar"},
+	})
+	w.Mount("/v1", func(g web.Group) {
+		g.Version("v1")
+		g.Api(web.Api{
+			Method:  http.MethodGet,
+			Path:    "/users",
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		})
+		g.Api(web.Api{
+			Method:  http.MethodGet,
+			Path:    "/groups",
+			Sunset:  time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		})
+	})
+
+	resp, _ := serve(t, w, httptest.NewRequest(http.MethodGet, "/v1/users", nil))
+	if resp.Header.Get("Deprecation") != "@1780272000" || resp.Header.Get("Sunset") != "Fri, 01 Jan 2027 00:00:00 GMT" {
+		t.Errorf("expected the headers of the version, got %v", resp.Header)
+	}
+	resp, _ = serve(t, w, httptest.NewRequest(http.MethodGet, "/v1/groups", nil))
+	if resp.Header.Get("Deprecation") != "@1780272000" || resp.Header.Get("Sunset") != "Tue, 01 Sep 2026 00:00:00 GMT" {
+		t.Errorf("expected the sunset of the api to take precedence, got %v", resp.Header)
+	}
+	if lookup(specAt(t, w, "/v1/doc.json"), "paths", "/v1/users", "get", "deprecated") != true {
+		t.Error("expected the operation to be deprecated in the version")
+	}
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"iter"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Instantan/web"
@@ -14,6 +17,11 @@ type ResponseTest struct {
 	Say string `json:"say"`
 }
 
+type HelloRequest struct {
+	Name  string `path:"name" description:"The name to say hello to"`
+	Times int    `query:"times,optional" description:"How often to say hello"`
+}
+
 func main() {
 	w := web.NewWeb()
 
@@ -82,6 +90,15 @@ func main() {
 		}),
 	})
 
+	w.Api(web.Api{
+		Method:      http.MethodGet,
+		Path:        "/hello/{name}",
+		Description: "Parameter and Responses are derived from the handler types",
+		Handler: web.Handle(func(r *http.Request, in HelloRequest) (ResponseTest, error) {
+			return ResponseTest{Say: strings.TrimSpace(strings.Repeat("Hello "+in.Name+" ", max(in.Times, 1)))}, nil
+		}),
+	})
+
 	w.Api(web.Api{
 		Method:    http.MethodGet,
 		Path:      "/test",
@@ -95,26 +112,39 @@ func main() {
 		}),
 	})
 
-	w.Api(web.Api{
-		Method:    http.MethodGet,
-		Path:      "/sse",
-		Parameter: web.Parameter{},
-		Responses: web.Responses{
-			StatusOK: ResponseTest{Say: "Hello world"},
-		},
-		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			log.Printf("%v", r.Header)
-			w.Header().Set("Content-Type", "text/event-stream")
-			w.Header().Set("Cache-Control", "no-cache")
-			w.Header().Set("Connection", "keep-alive")
-
-			for i := 0; i < 10; i++ {
-				fmt.Fprintf(w, "data: %s\n\n", fmt.Sprintf("Event %d", i))
-				time.Sleep(2 * time.Second)
-				w.(http.Flusher).Flush()
-			}
+	w.Sse(web.Sse{
+		Path:        "/sse",
+		Description: "Counts up every two seconds, resuming after the Last-Event-ID",
+		Handler: web.Events(func(r *http.Request, in struct{}) (iter.Seq[web.Event[ResponseTest]], error) {
+			start, _ := strconv.Atoi(web.LastEventId(r))
+			return func(yield func(web.Event[ResponseTest]) bool) {
+				for i := start + 1; i <= 10; i++ {
+					select {
+					case <-r.Context().Done():
+						return
+					case <-time.After(2 * time.Second):
+					}
+					if !yield(web.Event[ResponseTest]{Id: strconv.Itoa(i), Data: ResponseTest{Say: fmt.Sprintf("Event %d", i)}}) {
+						return
+					}
+				}
+			}, nil
+		}),
+	})
 
-			<-r.Context().Done()
+	w.Socket(web.Socket{
+		Path:        "/echo",
+		Description: "Answers every message with the same text",
+		Handler: web.Messages(func(r *http.Request, in struct{}, conn *web.Conn[ResponseTest, ResponseTest]) error {
+			for {
+				message, err := conn.Receive()
+				if err != nil {
+					return err
+				}
+				if err := conn.Send(message); err != nil {
+					return err
+				}
+			}
 		}),
 	})
 
@@ -0,0 +1,148 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+)
+
+type typedHandler interface {
+	http.Handler
+	describe(api *Api)
+}
+
+type handler[In, Out any] struct {
+	handler func(r *http.Request, in In) (Out, error)
+	binder  *binder
+}
+
+// Handle creates a http.Handler which binds the request to In and encodes the
+// returned Out. Fields of In are bound by their path, query, header and cookie
+// tags, a field named Body is decoded from the request body. When used as the
+// Handler of an Api the Parameter and Responses are derived from In and Out.
+func Handle[In, Out any](h func(r *http.Request, in In) (Out, error)) http.Handler {
+	return &handler[In, Out]{
+		handler: h,
+		binder:  newBinder(reflect.TypeFor[In]()),
+	}
+}
+
+func (h *handler[In, Out]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var in In
+	defer func() {
+		if r.MultipartForm != nil {
+			r.MultipartForm.RemoveAll()
+		}
+	}()
+	if err := h.binder.bind(r, reflect.ValueOf(&in).Elem()); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	out, err := h.handler(r, in)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeValue(w, r, http.StatusOK, out)
+}
+
+func (h *handler[In, Out]) describe(api *Api) {
+	h.binder.describe(api)
+	if api.Responses.StatusOK == nil {
+		api.Responses.Set(http.StatusOK, exampleOf(reflect.TypeFor[Out]()))
+	}
+}
+
+// describe derives the Parameter of the Api from the bound fields
+func (b *binder) describe(api *Api) {
+	// api is a copy, its maps are still shared with the declared Api
+	api.Parameter.Path = maps.Clone(api.Parameter.Path)
+	api.Parameter.Query = maps.Clone(api.Parameter.Query)
+	api.Parameter.Header = maps.Clone(api.Parameter.Header)
+	api.Parameter.Cookie = maps.Clone(api.Parameter.Cookie)
+	for _, field := range b.fields {
+		value := exampleOf(field.typ)
+		switch field.in {
+		case "path":
+			if api.Parameter.Path == nil {
+				api.Parameter.Path = Path{}
+			}
+			api.Parameter.Path[field.name] = PathParam{
+				Description: field.description,
+				Value:       value,
+				Validate:    field.validate,
+			}
+		case "query":
+			if api.Parameter.Query == nil {
+				api.Parameter.Query = Query{}
+			}
+			api.Parameter.Query[field.name] = QueryParam{
+				Optional:    field.optional,
+				Deprecated:  field.deprecated,
+				Description: field.description,
+				Value:       value,
+				Validate:    field.validate,
+			}
+		case "header":
+			if api.Parameter.Header == nil {
+				api.Parameter.Header = Header{}
+			}
+			api.Parameter.Header[field.name] = HeaderField{
+				Optional:    field.optional,
+				Deprecated:  field.deprecated,
+				Description: field.description,
+				Value:       value,
+				Validate:    field.validate,
+			}
+		case "cookie":
+			if api.Parameter.Cookie == nil {
+				api.Parameter.Cookie = Cookie{}
+			}
+			api.Parameter.Cookie[field.name] = CookieField{
+				Optional:    field.optional,
+				Description: field.description,
+				Value:       value,
+				Validate:    field.validate,
+			}
+		}
+	}
+	if b.body != nil && api.Parameter.Body.Value == nil {
+		api.Parameter.Body = Body{
+			Description: b.body.description,
+			Optional:    b.body.optional,
+			Value:       exampleOf(b.body.typ),
+		}
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	WriteProblem(w, r, ProblemOf(err))
+}
+
+// writeValue encodes the value with the codec of the negotiated content type,
+// without negotiation the first codec supporting the value is used
+func writeValue(w http.ResponseWriter, r *http.Request, status int, value any) {
+	c := codecsOf(r)
+	contentType := NegotiatedContentType(r)
+	if contentType == "" {
+		contentType = "application/json"
+		if supported := c.supporting(reflect.TypeOf(value)); len(supported) > 0 {
+			contentType = supported[0]
+		}
+	}
+	codec, ok := c.lookup(contentType)
+	if !ok {
+		writeError(w, r, fmt.Errorf("no codec for %v", contentType))
+		return
+	}
+	body := &bytes.Buffer{}
+	if err := codec.Encode(body, value); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(body.Bytes())
+}
@@ -0,0 +1,86 @@
+package web_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+func TestHandleKeepsDeclaredParameters(t *testing.T) {
+	query := web.Query{"q": web.QueryParam{Value: ""}}
+	w := newWeb()
+	w.Api(web.Api{
+		Method:    http.MethodGet,
+		Path:      "/search",
+		Parameter: web.Parameter{Query: query},
+		Handler: web.Handle(func(r *http.Request, in struct {
+			Limit int `query:"limit,optional"`
+		}) (string, error) {
+			return "", nil
+		}),
+	})
+	w.Api(web.Api{
+		Method:    http.MethodGet,
+		Path:      "/suggest",
+		Parameter: web.Parameter{Query: query},
+		Handler:   http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	})
+	doc := spec(t, w)
+
+	if _, ok := query["limit"]; ok {
+		t.Error("expected the declared parameters not to be modified")
+	}
+	parameters, _ := lookup(doc, "paths", "/suggest", "get").(map[string]any)["parameters"].([]any)
+	if len(parameters) != 1 {
+		t.Errorf("expected only the declared parameter on /suggest, got %v", parameters)
+	}
+}
+
+func TestHandleBindsAndEncodes(t *testing.T) {
+	type greeting struct {
+		Say string `json:"say"`
+	}
+	w := newWeb()
+	w.Api(web.Api{
+		Method: http.MethodPost,
+		Path:   "/hello/{name}",
+		Handler: web.Handle(func(r *http.Request, in struct {
+			Name     string `path:"name"`
+			Times    int    `query:"times,optional"`
+			Language string `header:"X-Language"`
+			Body     greeting
+		}) (greeting, error) {
+			return greeting{Say: fmt.Sprintf("%v %v %v %v", in.Body.Say, in.Name, in.Times, in.Language)}, nil
+		}),
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/hello/gopher?times=2", strings.NewReader(`{"say":"hi"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Language", "en")
+	resp, body := serve(t, w, r)
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(body) != `{"say":"hi gopher 2 en"}` {
+		t.Errorf("expected the bound request to be echoed, got %v %v", resp.StatusCode, body)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/hello/gopher?times=many", strings.NewReader(`{"say":"hi"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Language", "en")
+	if resp, body := serve(t, w, r); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a malformed parameter to be rejected, got %v %v", resp.StatusCode, body)
+	}
+
+	operation := lookup(spec(t, w), "paths", "/hello/{name}", "post").(map[string]any)
+	if parameters := operation["parameters"].([]any); len(parameters) != 3 {
+		t.Errorf("expected the path, query and header parameters, got %v", parameters)
+	}
+	if lookup(operation, "requestBody", "content", "application/json", "schema") == nil {
+		t.Errorf("expected the body to be documented, got %v", operation)
+	}
+	if lookup(operation, "responses", "200", "content", "application/json", "schema") == nil {
+		t.Errorf("expected the response to be documented, got %v", operation)
+	}
+}
@@ -9,12 +9,12 @@ type notFoundRedirectRespWr struct {
 	status              int
 }
 
-func createSpaModeRedirect(h http.Handler) http.HandlerFunc {
+func createSpaModeRedirect(h http.Handler, pathPrefix string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		nfrw := &notFoundRedirectRespWr{ResponseWriter: w}
 		h.ServeHTTP(nfrw, r)
 		if nfrw.status == 404 {
-			r.URL.Path = "/"
+			r.URL.Path = pathPrefix
 			w.Header().Add("content-type", "text/html")
 			h.ServeHTTP(nfrw, r)
 		}
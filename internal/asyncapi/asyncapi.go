@@ -0,0 +1,171 @@
+package asyncapi
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Instantan/web/internal/openapi"
+)
+
+// FromOpenApi describes the server-sent event and websocket operations of the
+// OpenAPI document as channels. Their payloads reference the schemas of the
+// OpenAPI components, which are shared with the document.
+func FromOpenApi(oa openapi.OpenAPI) AsyncAPI {
+	doc := AsyncAPI{
+		AsyncApi: "3.0.0",
+		Info: Info{
+			Title:          oa.Info.Title,
+			Version:        oa.Info.Version,
+			Description:    oa.Info.Description,
+			TermsOfService: oa.Info.TermsOfService,
+			Contact:        oa.Info.Contact,
+			License:        oa.Info.License,
+		},
+		DefaultContentType: "application/json",
+		Channels:           map[string]Channel{},
+		Operations:         map[string]Operation{},
+		Components: Components{
+			Schemas: oa.Components.Schemas,
+		},
+	}
+
+	for route, path := range oa.Paths.Iterate() {
+		for method, operation := range path.IterateOperations() {
+			events, isEventStream := operation.Responses.HTTPStatusCodeResponses["200"].Content["text/event-stream"]
+			if !isEventStream && operation.WebSocket == nil {
+				continue
+			}
+			id := channelId(route, operation)
+			channel := Channel{
+				Address:     route,
+				Summary:     operation.Summary,
+				Description: operation.Description,
+				Messages:    map[string]Message{},
+				Parameters:  parameters(operation),
+			}
+			query := nonEmpty(operation.QuerySchema())
+
+			if isEventStream {
+				channel.Servers = addServers(&doc, oa.Servers, false)
+				channel.Messages["event"] = Message{
+					Name:        "event",
+					ContentType: "application/json",
+					Payload:     &events.Schema,
+				}
+				doc.Operations[id] = Operation{
+					Action:      "send",
+					Channel:     Reference{Ref: "#/channels/" + id},
+					Summary:     operation.Summary,
+					Description: operation.Description,
+					Messages:    []Reference{{Ref: "#/channels/" + id + "/messages/event"}},
+					Bindings: &OperationBindings{
+						Http: &HttpOperationBinding{Method: method, Query: query},
+					},
+				}
+			} else {
+				channel.Servers = addServers(&doc, oa.Servers, true)
+				channel.Bindings = &ChannelBindings{
+					Ws: &WebSocketChannelBinding{
+						Method:  method,
+						Query:   query,
+						Headers: nonEmpty(operation.HeaderSchema()),
+					},
+				}
+				channel.Messages["receive"] = Message{Name: "receive", ContentType: "application/json", Payload: operation.WebSocket.Receive}
+				channel.Messages["send"] = Message{Name: "send", ContentType: "application/json", Payload: operation.WebSocket.Send}
+				for _, action := range []string{"receive", "send"} {
+					doc.Operations[id+strings.ToUpper(action[:1])+action[1:]] = Operation{
+						Action:      action,
+						Channel:     Reference{Ref: "#/channels/" + id},
+						Summary:     operation.Summary,
+						Description: operation.Description,
+						Messages:    []Reference{{Ref: "#/channels/" + id + "/messages/" + action}},
+					}
+				}
+			}
+			doc.Channels[id] = channel
+		}
+	}
+	return doc
+}
+
+// channelId returns the operationId or a camel case id derived from the path
+func channelId(route string, operation *openapi.Operation) string {
+	if operation.OperationId != "" {
+		return operation.OperationId
+	}
+	id := strings.Builder{}
+	upper := false
+	for _, r := range route {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = id.Len() > 0
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		id.WriteRune(r)
+	}
+	if id.Len() == 0 {
+		return "root"
+	}
+	return id.String()
+}
+
+func parameters(operation *openapi.Operation) map[string]Parameter {
+	parameters := map[string]Parameter{}
+	for _, parameter := range operation.Parameters {
+		if parameter.In == "path" {
+			parameters[parameter.Name] = Parameter{Description: parameter.Description}
+		}
+	}
+	if len(parameters) == 0 {
+		return nil
+	}
+	return parameters
+}
+
+func nonEmpty(schema openapi.Schema) *openapi.Schema {
+	if len(schema.Properties) == 0 {
+		return nil
+	}
+	return &schema
+}
+
+// addServers adds the absolute servers of the OpenAPI document with the
+// protocol of their scheme, websockets use ws and wss instead of http and
+// https. It returns references to the added servers.
+func addServers(doc *AsyncAPI, servers []openapi.Server, websocket bool) []Reference {
+	references := []Reference{}
+	for i, server := range servers {
+		protocol, rest, ok := strings.Cut(server.Url, "://")
+		if !ok {
+			continue
+		}
+		host, pathname, _ := strings.Cut(rest, "/")
+		if websocket {
+			protocol = strings.Replace(protocol, "http", "ws", 1)
+		}
+		name := protocol
+		if i > 0 {
+			name += strconv.Itoa(i)
+		}
+		if doc.Servers == nil {
+			doc.Servers = map[string]Server{}
+		}
+		doc.Servers[name] = Server{
+			Host:        host,
+			Protocol:    protocol,
+			Pathname:    strings.TrimSuffix("/"+pathname, "/"),
+			Description: server.Description,
+			Variables:   server.Variables,
+		}
+		references = append(references, Reference{Ref: "#/servers/" + name})
+	}
+	if len(references) == 0 {
+		return nil
+	}
+	return references
+}
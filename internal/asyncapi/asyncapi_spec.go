@@ -0,0 +1,144 @@
+// Package asyncapi describes the event-style routes of an api as AsyncAPI 3.0
+// document.
+package asyncapi
+
+import "github.com/Instantan/web/internal/openapi"
+
+type AsyncAPI struct {
+	// REQUIRED. The version of the AsyncAPI specification the document uses.
+	AsyncApi string `json:"asyncapi"`
+	// REQUIRED. Provides metadata about the API.
+	Info Info `json:"info"`
+	// Provides connection details of servers, keyed by their names.
+	Servers map[string]Server `json:"servers,omitempty"`
+	// Default content type to use when encoding/decoding a message's payload.
+	DefaultContentType string `json:"defaultContentType,omitempty"`
+	// The channels used by this application, keyed by their identifiers.
+	Channels map[string]Channel `json:"channels"`
+	// The operations this application MUST implement, keyed by their identifiers.
+	Operations map[string]Operation `json:"operations"`
+	// An element to hold various reusable objects for the specification.
+	Components Components `json:"components"`
+}
+
+type Info struct {
+	// REQUIRED. The title of the application.
+	Title string `json:"title"`
+	// REQUIRED. Provides the version of the application API.
+	Version string `json:"version"`
+	// A short description of the application. CommonMark syntax can be used
+	// for rich text representation.
+	Description string `json:"description,omitempty"`
+	// A URL to the Terms of Service for the API.
+	TermsOfService string `json:"termsOfService,omitempty"`
+	// The contact information for the exposed API.
+	Contact *openapi.Contact `json:"contact,omitempty"`
+	// The license information for the exposed API.
+	License *openapi.License `json:"license,omitempty"`
+}
+
+type Server struct {
+	// REQUIRED. The server host name. It MAY include the port and supports
+	// Server Variables in {brackets}.
+	Host string `json:"host"`
+	// REQUIRED. The protocol this server supports for connection.
+	Protocol string `json:"protocol"`
+	// The path to a resource in the host.
+	Pathname string `json:"pathname,omitempty"`
+	// An optional string describing the server.
+	Description string `json:"description,omitempty"`
+	// A map between a variable name and its value. The value is used for
+	// substitution in the server's host and pathname template.
+	Variables map[string]openapi.ServerVariable `json:"variables,omitempty"`
+}
+
+type Channel struct {
+	// The address of the channel, it MAY contain Parameters in {brackets}.
+	Address string `json:"address"`
+	// The messages that can be sent through the channel, keyed by their identifiers.
+	Messages map[string]Message `json:"messages"`
+	// A human-friendly title for the channel.
+	Title string `json:"title,omitempty"`
+	// A short summary of the channel.
+	Summary string `json:"summary,omitempty"`
+	// An optional description of this channel.
+	Description string `json:"description,omitempty"`
+	// The servers this channel is available on, empty means all servers.
+	Servers []Reference `json:"servers,omitempty"`
+	// A map of the parameters included in the channel address.
+	Parameters map[string]Parameter `json:"parameters,omitempty"`
+	// Protocol-specific information for the channel.
+	Bindings *ChannelBindings `json:"bindings,omitempty"`
+}
+
+type Parameter struct {
+	// An optional description for the parameter.
+	Description string `json:"description,omitempty"`
+}
+
+type ChannelBindings struct {
+	Ws *WebSocketChannelBinding `json:"ws,omitempty"`
+}
+
+// WebSocketChannelBinding describes the opening handshake of a websocket channel
+type WebSocketChannelBinding struct {
+	// The HTTP method to use when establishing the connection, GET or POST.
+	Method string `json:"method,omitempty"`
+	// A Schema object containing the definitions for each query parameter.
+	Query *openapi.Schema `json:"query,omitempty"`
+	// A Schema object containing the definitions of the HTTP headers to use
+	// when establishing the connection.
+	Headers *openapi.Schema `json:"headers,omitempty"`
+}
+
+type Operation struct {
+	// REQUIRED. Use send when the application will send messages and receive
+	// when the application expects to receive messages.
+	Action string `json:"action"`
+	// REQUIRED. A $ref pointer to the definition of the channel in which this
+	// operation is performed.
+	Channel Reference `json:"channel"`
+	// A human-friendly title for the operation.
+	Title string `json:"title,omitempty"`
+	// A short summary of what the operation is about.
+	Summary string `json:"summary,omitempty"`
+	// A verbose explanation of the operation.
+	Description string `json:"description,omitempty"`
+	// A list of $ref pointers to the supported messages of the channel that
+	// can be processed by this operation.
+	Messages []Reference `json:"messages,omitempty"`
+	// Protocol-specific information for the operation.
+	Bindings *OperationBindings `json:"bindings,omitempty"`
+}
+
+type OperationBindings struct {
+	Http *HttpOperationBinding `json:"http,omitempty"`
+}
+
+// HttpOperationBinding describes the request of an operation over plain HTTP
+type HttpOperationBinding struct {
+	// The HTTP method for the request.
+	Method string `json:"method,omitempty"`
+	// A Schema object containing the definitions for each query parameter.
+	Query *openapi.Schema `json:"query,omitempty"`
+}
+
+type Message struct {
+	// A machine-friendly name for the message.
+	Name string `json:"name,omitempty"`
+	// A human-friendly title for the message.
+	Title string `json:"title,omitempty"`
+	// The content type to use when encoding/decoding a message's payload.
+	ContentType string `json:"contentType,omitempty"`
+	// Definition of the message payload.
+	Payload *openapi.Schema `json:"payload,omitempty"`
+}
+
+type Reference struct {
+	Ref string `json:"$ref"`
+}
+
+type Components struct {
+	// An object to hold reusable Schema Objects.
+	Schemas map[string]openapi.Schema `json:"schemas,omitempty"`
+}
@@ -0,0 +1,66 @@
+package asyncapi_test
+
+import (
+	"testing"
+
+	"github.com/Instantan/web/internal/asyncapi"
+	"github.com/Instantan/web/internal/openapi"
+)
+
+func TestFromOpenApi(t *testing.T) {
+	type Tick struct {
+		N int `json:"n"`
+	}
+	paths := openapi.Paths{}
+	paths.Set("/ticks", openapi.PathItem{Get: &openapi.Operation{
+		Responses: openapi.Responses{HTTPStatusCodeResponses: map[string]openapi.Response{
+			"200": {Content: map[string]openapi.MediaType{
+				"text/event-stream": {Schema: *openapi.ValueToSchema(Tick{})},
+			}},
+		}},
+	}})
+	paths.Set("/rooms/{room}", openapi.PathItem{Get: &openapi.Operation{
+		OperationId: "chat",
+		Parameters:  []openapi.Parameter{{Name: "room", In: "path", Description: "The room to join"}},
+		WebSocket: &openapi.WebSocket{
+			Receive: &openapi.Schema{Ref: "#/components/schemas/Message"},
+			Send:    &openapi.Schema{Ref: "#/components/schemas/Message"},
+		},
+	}})
+	paths.Set("/users", openapi.PathItem{Get: &openapi.Operation{}})
+
+	doc := asyncapi.FromOpenApi(openapi.OpenAPI{
+		Info:    openapi.Info{Title: "Test", Version: "1"},
+		Servers: []openapi.Server{{Url: "https://example.com/api"}, {Url: "/relative"}},
+		Paths:   paths,
+	})
+
+	if len(doc.Channels) != 2 {
+		t.Fatalf("expected the event stream and the socket as channels, got %v", doc.Channels)
+	}
+	ticks, ok := doc.Channels["ticks"]
+	if !ok || ticks.Address != "/ticks" || ticks.Messages["event"].Payload.Properties["n"] == nil {
+		t.Fatalf("unexpected event channel %+v", ticks)
+	}
+	if operation := doc.Operations["ticks"]; operation.Action != "send" || operation.Channel.Ref != "#/channels/ticks" {
+		t.Fatalf("unexpected event operation %+v", operation)
+	}
+
+	chat, ok := doc.Channels["chat"]
+	if !ok || chat.Parameters["room"].Description != "The room to join" || chat.Bindings.Ws.Method != "GET" {
+		t.Fatalf("unexpected socket channel %+v", chat)
+	}
+	if operation := doc.Operations["chatReceive"]; operation.Action != "receive" || operation.Messages[0].Ref != "#/channels/chat/messages/receive" {
+		t.Fatalf("unexpected receive operation %+v", operation)
+	}
+	if operation := doc.Operations["chatSend"]; operation.Action != "send" {
+		t.Fatalf("unexpected send operation %+v", operation)
+	}
+
+	if len(doc.Servers) != 2 || doc.Servers["wss"].Host != "example.com" || doc.Servers["https"].Pathname != "/api" {
+		t.Fatalf("expected the absolute server for http and websockets, got %+v", doc.Servers)
+	}
+	if chat.Servers[0].Ref != "#/servers/wss" {
+		t.Fatalf("expected the socket to be served by wss, got %v", chat.Servers)
+	}
+}
@@ -2,6 +2,7 @@ package generate
 
 import (
 	"bytes"
+	"encoding/json"
 	"slices"
 	"strings"
 
@@ -102,12 +103,17 @@ func (t *tsGenerator) schema(s openapi.Schema) *tsGenerator {
 }
 
 func (t *tsGenerator) doc(lines []string) *tsGenerator {
-	must(t.b.WriteString("/**\n"))
+	t.intent()
+	must(t.b.WriteString("/**"))
+	t.newline()
 	for _, line := range lines {
+		t.intent()
 		must(t.b.WriteString(" *  "))
-		must(t.b.WriteString(line))
-		must(t.b.WriteString("\n"))
+		// a */ in the line would end the comment early
+		must(t.b.WriteString(strings.ReplaceAll(line, "*/", "*\\/")))
+		t.newline()
 	}
+	t.intent()
 	must(t.b.WriteString("*/"))
 	t.newline()
 	return t
@@ -136,17 +142,31 @@ func (t *tsGenerator) bytes() []byte {
 }
 
 func writeSchemaToBuffer(b *bytes.Buffer, schema openapi.Schema, indentLevel int) {
+	if schema.Nullable {
+		schema.Nullable = false
+		writeSchemaToBuffer(b, schema, indentLevel)
+		must(b.WriteString(" | null"))
+		return
+	}
 	if schema.Ref != "" {
 		must(b.WriteString(extractSchemaName(schema.Ref)))
 		return
 	}
+	if len(schema.Enum) > 0 {
+		writeEnum(b, schema.Enum)
+		return
+	}
 	indent := strings.Repeat("	", indentLevel)
 	switch schema.Type {
 	case "object":
 		if len(schema.Properties) > 0 {
 			must(b.WriteString("{\n"))
 			for propName, propSchema := range schema.Properties {
-				must(b.WriteString(indent + "  " + propName))
+				must(b.WriteString(indent + "  "))
+				if propSchema.Deprecated {
+					must(b.WriteString("/** @deprecated */ "))
+				}
+				must(b.WriteString(propName))
 				if !slices.Contains(schema.Required, propName) {
 					must(b.WriteString("?"))
 				}
@@ -155,14 +175,32 @@ func writeSchemaToBuffer(b *bytes.Buffer, schema openapi.Schema, indentLevel int
 				must(b.WriteString(";\n"))
 			}
 			b.WriteString(indent + "}")
+		} else if schema.AdditionalProperties != nil {
+			must(b.WriteString("Record<string, "))
+			writeSchemaToBuffer(b, *schema.AdditionalProperties, indentLevel)
+			must(b.WriteString(">"))
 		} else {
 			must(b.WriteString("never"))
 		}
 	case "array":
+		if schema.Items == nil {
+			must(b.WriteString("any[]"))
+			return
+		}
+		if len(schema.Items.Enum) > 1 {
+			must(b.WriteString("("))
+			writeSchemaToBuffer(b, *schema.Items, indentLevel)
+			must(b.WriteString(")[]"))
+			return
+		}
 		writeSchemaToBuffer(b, *schema.Items, indentLevel)
 		must(b.WriteString("[]"))
 	case "string":
-		must(b.WriteString("string"))
+		if schema.Format == "binary" {
+			must(b.WriteString("Blob"))
+		} else {
+			must(b.WriteString("string"))
+		}
 	case "number", "integer":
 		must(b.WriteString("number"))
 	case "boolean":
@@ -173,3 +211,22 @@ func writeSchemaToBuffer(b *bytes.Buffer, schema openapi.Schema, indentLevel int
 		must(b.WriteString("any"))
 	}
 }
+
+// writeEnum writes the values as union of literal types, e.g. 'a' | 'b'
+func writeEnum(b *bytes.Buffer, values []any) {
+	for i, value := range values {
+		if i > 0 {
+			must(b.WriteString(" | "))
+		}
+		literal, err := json.Marshal(value)
+		if err != nil {
+			must(b.WriteString("never"))
+			continue
+		}
+		var s string
+		if json.Unmarshal(literal, &s) == nil {
+			literal = []byte("'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`).Replace(s) + "'")
+		}
+		must(b.Write(literal))
+	}
+}
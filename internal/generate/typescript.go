@@ -2,6 +2,10 @@ package generate
 
 import (
 	"bytes"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/Instantan/web/internal/openapi"
 )
@@ -10,6 +14,7 @@ const typescriptFetchClient = `
 
 type ClientOptions = {
 	url?: string
+	credentials?: Credentials
 	beforeRequest?: (api: {
 		method: any,
 		path: any,
@@ -28,26 +33,17 @@ type ClientOptions = {
 }
 
 function createClient(options?: ClientOptions): Api {
-	const url = options?.url ? options.url : ''
+	const url = options?.url ? options.url : defaultServerUrl
 	return (async (api: any) => {
 		if (options?.beforeRequest) {
 			options.beforeRequest(api)
 		}
-		const query = new URLSearchParams()
-		const queryObj = api?.params?.query || {}
-		Object.keys(queryObj).forEach(name => query.set(name, queryObj[name]))
-		const queryString = (query.size > 0 ? '?' + query.toString() : '')
-		const pathParams = api?.params?.path || {}
-		const path = api.path.replace(/{(\w+)}/g, (_, key) => 
-			pathParams[key] !== undefined ? pathParams[key] : '{' + key + '}'
-		)
-		const resp = await fetch(url + path + queryString, {
-			method: api.method,
-			body: api?.params?.body,
-		})
+		const resp = await send(url, options, api, {})
+		const contentType = resp.headers.get('content-type') || ''
 		const result = {
 			status: resp.status,
-			body: await resp.json()
+			body: contentType.includes('json') ? await resp.json() :
+				contentType.startsWith('text/') || contentType.includes('xml') || contentType.includes('form-urlencoded') ? await resp.text() : await resp.blob()
 		}
 		if (options?.afterRequest) {
 			options.afterRequest(result)
@@ -56,8 +52,257 @@ function createClient(options?: ClientOptions): Api {
 	}) as Api
 }
 
+type ServerSentEvent<T> = {
+	id?: string
+	event?: string
+	data: T
+}
+
+function createEventClient(options?: ClientOptions): Events {
+	const url = options?.url ? options.url : defaultServerUrl
+	return (async function* (api: any) {
+		if (options?.beforeRequest) {
+			options.beforeRequest(api)
+		}
+		const resp = await send(url, options, api, { 'Accept': 'text/event-stream' })
+		if (!resp.ok || !resp.body) {
+			throw new Error('event stream responded with status ' + resp.status)
+		}
+		yield* readEvents(resp.body)
+	}) as Events
+}
+
+async function* readEvents(body: ReadableStream<Uint8Array>): AsyncGenerator<ServerSentEvent<any>> {
+	const reader = body.pipeThrough(new TextDecoderStream()).getReader()
+	let buffer = ''
+	let event: { id?: string, event?: string, data: string[] } = { data: [] }
+	try {
+		while (true) {
+			const { value, done } = await reader.read()
+			if (done) {
+				return
+			}
+			buffer += value
+			const lines = buffer.split(/\r\n|\n/)
+			buffer = lines.pop() || ''
+			for (const line of lines) {
+				if (line === '') {
+					if (event.data.length > 0) {
+						yield { id: event.id, event: event.event, data: JSON.parse(event.data.join('\n')) }
+					}
+					event = { data: [] }
+					continue
+				}
+				if (line.startsWith(':')) {
+					continue
+				}
+				const index = line.indexOf(':')
+				const field = index < 0 ? line : line.slice(0, index)
+				const value = index < 0 ? '' : line.slice(index + 1).replace(/^ /, '')
+				if (field === 'data') {
+					event.data.push(value)
+				} else if (field === 'id') {
+					event.id = value
+				} else if (field === 'event') {
+					event.event = value
+				}
+			}
+		}
+	} finally {
+		reader.cancel()
+	}
+}
+
+function createStreamClient(options?: ClientOptions): Streams {
+	const url = options?.url ? options.url : defaultServerUrl
+	return (async function* (api: any) {
+		if (options?.beforeRequest) {
+			options.beforeRequest(api)
+		}
+		const resp = await send(url, options, api, { 'Accept': 'application/x-ndjson' })
+		if (!resp.ok || !resp.body) {
+			throw new Error('stream responded with status ' + resp.status)
+		}
+		yield* readLines(resp.body)
+	}) as Streams
+}
+
+async function* readLines(body: ReadableStream<Uint8Array>): AsyncGenerator<any> {
+	const reader = body.pipeThrough(new TextDecoderStream()).getReader()
+	let buffer = ''
+	try {
+		while (true) {
+			const { value, done } = await reader.read()
+			if (done) {
+				if (buffer.trim() !== '') {
+					yield JSON.parse(buffer)
+				}
+				return
+			}
+			buffer += value
+			const lines = buffer.split('\n')
+			buffer = lines.pop() || ''
+			for (const line of lines) {
+				if (line.trim() !== '') {
+					yield JSON.parse(line)
+				}
+			}
+		}
+	} finally {
+		reader.cancel()
+	}
+}
+
+type Socket<In, Out> = AsyncIterable<In> & {
+	send(message: Out): Promise<void>
+	close(code?: number, reason?: string): void
+	raw: WebSocket
+}
+
+function createSocketClient(options?: ClientOptions): Sockets {
+	const url = options?.url ? options.url : defaultServerUrl
+	return ((api: any) => {
+		if (options?.beforeRequest) {
+			options.beforeRequest(api)
+		}
+		// browsers can not set headers on websockets, credentials are only sent as query or cookie
+		const target = new URL(resolve(url, options, api, {}), globalThis.location?.href)
+		target.protocol = target.protocol === 'https:' ? 'wss:' : 'ws:'
+		return openSocket(new WebSocket(target))
+	}) as Sockets
+}
+
+function openSocket(ws: WebSocket): Socket<any, any> {
+	const messages: any[] = []
+	const waiting: ((result: IteratorResult<any>) => void)[] = []
+	let closed = false
+	const opened = new Promise<void>((resolve, reject) => {
+		ws.onopen = () => resolve()
+		ws.onerror = () => reject(new Error('socket could not be opened'))
+	})
+	ws.onmessage = (event) => {
+		const message = JSON.parse(event.data)
+		const next = waiting.shift()
+		next ? next({ value: message, done: false }) : messages.push(message)
+	}
+	ws.onclose = () => {
+		closed = true
+		waiting.splice(0).forEach(next => next({ value: undefined, done: true }))
+	}
+	return {
+		raw: ws,
+		async send(message: any) {
+			await opened
+			ws.send(JSON.stringify(message))
+		},
+		close(code?: number, reason?: string) {
+			ws.close(code, reason)
+		},
+		[Symbol.asyncIterator]() {
+			return {
+				next: (): Promise<IteratorResult<any>> => {
+					if (messages.length > 0) {
+						return Promise.resolve({ value: messages.shift(), done: false })
+					}
+					if (closed) {
+						return Promise.resolve({ value: undefined, done: true })
+					}
+					return new Promise(next => waiting.push(next))
+				},
+				return: async (): Promise<IteratorResult<any>> => {
+					ws.close()
+					return { value: undefined, done: true }
+				}
+			}
+		}
+	}
+}
+
+// resolve returns the url of the call, the credentials are applied to the headers
+function resolve(url: string, options: ClientOptions | undefined, api: any, headers: Record<string, string>): string {
+	const query = new URLSearchParams()
+	const queryObj = api?.params?.query || {}
+	Object.keys(queryObj).forEach(name => query.set(name, queryObj[name]))
+	applyCredentials(api.method + ' ' + api.path, options?.credentials, headers, query)
+	const queryString = (query.size > 0 ? '?' + query.toString() : '')
+	const pathParams = api?.params?.path || {}
+	const path = api.path.replace(/{(\w+)}/g, (_: string, key: string) =>
+		pathParams[key] !== undefined ? pathParams[key] : '{' + key + '}'
+	)
+	return url + path + queryString
+}
+
+async function send(url: string, options: ClientOptions | undefined, api: any, defaultHeaders: Record<string, string>): Promise<Response> {
+	const headers: Record<string, string> = { ...defaultHeaders, ...(api?.params?.header || {}) }
+	const target = resolve(url, options, api, headers)
+	let body = api?.params?.body
+	if (body !== undefined && multipartOperations.includes(api.method + ' ' + api.path)) {
+		body = toFormData(body)
+	} else if (body !== undefined && !(typeof body === 'string' || body instanceof Blob || body instanceof FormData || body instanceof URLSearchParams || body instanceof ArrayBuffer)) {
+		body = JSON.stringify(body)
+		if (!Object.keys(headers).some(name => name.toLowerCase() === 'content-type')) {
+			headers['Content-Type'] = 'application/json'
+		}
+	}
+	return fetch(target, {
+		method: api.method,
+		headers: headers,
+		body: body,
+		credentials: usesCookies(api.method + ' ' + api.path) ? 'include' : 'same-origin',
+	})
+}
+
+function toFormData(body: Record<string, any>): FormData {
+	const form = new FormData()
+	for (const name of Object.keys(body)) {
+		const values = Array.isArray(body[name]) ? body[name] : [body[name]]
+		for (const value of values) {
+			if (value === undefined || value === null) {
+				continue
+			}
+			form.append(name, value instanceof Blob ? value : String(value))
+		}
+	}
+	return form
+}
+
+function applyCredentials(operation: string, credentials: Credentials | undefined, headers: Record<string, string>, query: URLSearchParams) {
+	const values = (credentials || {}) as Record<string, any>
+	const requirement = (operationSecurity[operation] || []).find(names => names.every(name => values[name] !== undefined || isCookie(name)))
+	for (const name of requirement || []) {
+		if (isCookie(name)) {
+			// browsers forbid the Cookie header, fetch sends the cookie itself
+			continue
+		}
+		const scheme = securitySchemes[name]
+		const value = values[name]
+		if (scheme.type === 'apiKey' && scheme.in === 'header') {
+			headers[scheme.name!] = value
+		} else if (scheme.type === 'apiKey' && scheme.in === 'query') {
+			query.set(scheme.name!, value)
+		} else if (scheme.type === 'http' && scheme.scheme?.toLowerCase() === 'basic') {
+			headers['Authorization'] = 'Basic ' + btoa(value.username + ':' + value.password)
+		} else {
+			headers['Authorization'] = 'Bearer ' + value
+		}
+	}
+}
+
+function isCookie(name: string): boolean {
+	return securitySchemes[name].type === 'apiKey' && securitySchemes[name].in === 'cookie'
+}
+
+// usesCookies reports whether the operation can be authenticated by a cookie,
+// fetch only sends cookies to other origins if it is told to include them
+function usesCookies(operation: string): boolean {
+	return (operationSecurity[operation] || []).some(names => names.some(isCookie))
+}
+
 export {
-	createClient
+	createClient,
+	createEventClient,
+	createStreamClient,
+	createSocketClient
 }
 `
 
@@ -73,53 +318,15 @@ func GenerateTypescriptModels(api openapi.OpenAPI) []byte {
 		t.name("interface").s(" ").name("Api").s(" ").scope(func(t *tsGenerator) {
 			for route, path := range api.Paths.Iterate() {
 				for method, operation := range path.IterateOperations() {
-					t.braces(func(t *tsGenerator) {
-						t.name("api").colon().scope(func(t *tsGenerator) {
-							t.name("method").colon().s("'" + method + "'").newline()
-							t.name("path").colon().s("'" + route + "'").newline()
-
-							querySchema := operation.QuerySchema()
-							cookieSchema := operation.CookieSchema()
-							pathSchema := operation.PathSchema()
-							headerSchema := operation.HeaderSchema()
-
-							t.name("params").colon().scope(func(t *tsGenerator) {
-								if len(pathSchema.Properties) > 0 {
-									t.name("path").colon().schema(pathSchema).newline()
-								}
-								if len(querySchema.Properties) > 0 {
-									t.name("query").colon().schema(querySchema).newline()
-								}
-								if len(cookieSchema.Properties) > 0 {
-									t.name("cookie").colon().schema(cookieSchema).newline()
-								}
-								if len(headerSchema.Properties) > 0 {
-									t.name("header").colon().s("Record<string, string> & ").schema(headerSchema)
-								} else {
-									t.name("header?").colon().s("Record<string, string>")
-								}
-							})
-							if operation.RequestBody != nil {
-								if !operation.RequestBody.Required {
-									t.name("body").colon()
-								} else {
-									t.name("body?").colon()
-								}
-								for _, content := range operation.RequestBody.Content {
-									t.schema(content.Schema).union().newline()
-								}
-								t.marker()
-							}
-						})
-					}).colon().name("Promise").generic(func(t *tsGenerator) {
+					if isEventStream(operation) || operation.WebSocket != nil {
+						continue
+					}
+					writeOperation(t, route, method, operation)
+					t.colon().name("Promise").generic(func(t *tsGenerator) {
 						for code, response := range operation.Responses.Iterate() {
 							t.scope(func(t *tsGenerator) {
 								t.name("status").colon().s(code).newline()
-								t.name("body").colon()
-								for _, mediatype := range response.Content {
-									t.schema(mediatype.Schema).union()
-								}
-								t.marker()
+								t.name("body").colon().s(schemaUnion(response.Content, t.goalIntent))
 							}).union()
 						}
 						t.marker()
@@ -130,11 +337,261 @@ func GenerateTypescriptModels(api openapi.OpenAPI) []byte {
 		}).newline().newline()
 	}
 
+	t.name("interface").s(" ").name("Events").s(" ").scope(func(t *tsGenerator) {
+		for route, path := range api.Paths.Iterate() {
+			for method, operation := range path.IterateOperations() {
+				if !isEventStream(operation) {
+					continue
+				}
+				writeOperation(t, route, method, operation)
+				t.colon().name("AsyncIterable").generic(func(t *tsGenerator) {
+					events := map[string]openapi.MediaType{
+						"text/event-stream": operation.Responses.HTTPStatusCodeResponses["200"].Content["text/event-stream"],
+					}
+					t.name("ServerSentEvent").generic(func(t *tsGenerator) {
+						t.s(schemaUnion(events, t.goalIntent))
+					})
+				}).semicolon().newline().newline()
+			}
+		}
+		t.marker()
+	}).newline().newline()
+
+	t.name("interface").s(" ").name("Streams").s(" ").scope(func(t *tsGenerator) {
+		for route, path := range api.Paths.Iterate() {
+			for method, operation := range path.IterateOperations() {
+				items, ok := streamItems(operation)
+				if !ok {
+					continue
+				}
+				writeOperation(t, route, method, operation)
+				t.colon().name("AsyncIterable").generic(func(t *tsGenerator) {
+					t.s(schemaUnion(items, t.goalIntent))
+				}).semicolon().newline().newline()
+			}
+		}
+		t.marker()
+	}).newline().newline()
+
+	t.name("interface").s(" ").name("Sockets").s(" ").scope(func(t *tsGenerator) {
+		for route, path := range api.Paths.Iterate() {
+			for method, operation := range path.IterateOperations() {
+				if operation.WebSocket == nil {
+					continue
+				}
+				writeOperation(t, route, method, operation)
+				// the client receives what the server sends and the other way around
+				t.colon().name("Socket").generic(func(t *tsGenerator) {
+					t.s(socketMessage(operation.WebSocket.Send, t.goalIntent)).s(", ")
+					t.s(socketMessage(operation.WebSocket.Receive, t.goalIntent))
+				}).semicolon().newline().newline()
+			}
+		}
+		t.marker()
+	}).newline().newline()
+
 	for name, schema := range api.Components.Schemas {
 		t.name("type").s(" ").name(name).assign().schema(schema).newline()
 	}
 
+	writeSecurity(t, api)
+	writeServers(t, api)
+	writeMultipart(t, api)
+
 	t.s(typescriptFetchClient)
 
 	return t.bytes()
 }
+
+// writeOperation writes the call signature of the operation without its result
+func writeOperation(t *tsGenerator, route string, method string, operation *openapi.Operation) {
+	if doc := operationDoc(operation); len(doc) > 0 {
+		t.doc(doc)
+	}
+	t.braces(func(t *tsGenerator) {
+		t.name("api").colon().scope(func(t *tsGenerator) {
+			t.name("method").colon().s("'" + method + "'").newline()
+			t.name("path").colon().s("'" + route + "'").newline()
+
+			querySchema := operation.QuerySchema()
+			cookieSchema := operation.CookieSchema()
+			pathSchema := operation.PathSchema()
+			headerSchema := operation.HeaderSchema()
+
+			t.name("params").colon().scope(func(t *tsGenerator) {
+				if len(pathSchema.Properties) > 0 {
+					t.name("path").colon().schema(pathSchema).newline()
+				}
+				if len(querySchema.Properties) > 0 {
+					t.name("query").colon().schema(querySchema).newline()
+				}
+				if len(cookieSchema.Properties) > 0 {
+					t.name("cookie").colon().schema(cookieSchema).newline()
+				}
+				if len(headerSchema.Properties) > 0 {
+					t.name("header").colon().s("Record<string, string> & ").schema(headerSchema)
+				} else {
+					t.name("header?").colon().s("Record<string, string>")
+				}
+				if operation.RequestBody != nil {
+					t.newline()
+					if operation.RequestBody.Required {
+						t.name("body").colon()
+					} else {
+						t.name("body?").colon()
+					}
+					t.intent().s(schemaUnion(operation.RequestBody.Content, t.goalIntent))
+				}
+			})
+		})
+	})
+}
+
+// isEventStream reports if the operation responds with server-sent events
+func isEventStream(operation *openapi.Operation) bool {
+	_, ok := operation.Responses.HTTPStatusCodeResponses["200"].Content["text/event-stream"]
+	return ok
+}
+
+// streamItems returns the schema of the items of an operation streaming
+// application/x-ndjson
+func streamItems(operation *openapi.Operation) (map[string]openapi.MediaType, bool) {
+	content, ok := operation.Responses.HTTPStatusCodeResponses["200"].Content["application/x-ndjson"]
+	if !ok {
+		return nil, false
+	}
+	if content.Schema.Items == nil {
+		return map[string]openapi.MediaType{}, true
+	}
+	return map[string]openapi.MediaType{"": {Schema: *content.Schema.Items}}, true
+}
+
+// socketMessage renders the schema of a websocket message
+func socketMessage(schema *openapi.Schema, indentLevel int) string {
+	if schema == nil {
+		return "unknown"
+	}
+	return schemaUnion(map[string]openapi.MediaType{"": {Schema: *schema}}, indentLevel)
+}
+
+// schemaUnion renders the distinct schemas of the media types as union
+func schemaUnion(content map[string]openapi.MediaType, indentLevel int) string {
+	types := []string{}
+	for _, mediaType := range slices.Sorted(maps.Keys(content)) {
+		b := &bytes.Buffer{}
+		writeSchemaToBuffer(b, content[mediaType].Schema, indentLevel)
+		if !slices.Contains(types, b.String()) {
+			types = append(types, b.String())
+		}
+	}
+	if len(types) == 0 {
+		return "unknown"
+	}
+	return strings.Join(types, " | ")
+}
+
+func writeSecurity(t *tsGenerator, api openapi.OpenAPI) {
+	schemeNames := slices.Sorted(maps.Keys(api.Components.SecuritySchemes))
+
+	t.newline().name("type").s(" ").name("Credentials").assign().scope(func(t *tsGenerator) {
+		for _, name := range schemeNames {
+			scheme := api.Components.SecuritySchemes[name]
+			if scheme.Type == "apiKey" && scheme.In == "cookie" {
+				// cookies are managed by the browser
+				continue
+			}
+			t.name(name + "?").colon()
+			if scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic") {
+				t.s("{ username: string, password: string }")
+			} else {
+				t.s("string")
+			}
+			t.newline()
+		}
+		t.marker()
+	}).newline().newline()
+
+	t.name("type").s(" ").name("SecurityScheme").assign().s("{ type: string, scheme?: string, in?: string, name?: string }").newline().newline()
+
+	schemes := map[string]map[string]string{}
+	for _, name := range schemeNames {
+		scheme := api.Components.SecuritySchemes[name]
+		schemes[name] = map[string]string{"type": scheme.Type}
+		if scheme.Scheme != "" {
+			schemes[name]["scheme"] = scheme.Scheme
+		}
+		if scheme.In != "" {
+			schemes[name]["in"] = scheme.In
+			schemes[name]["name"] = scheme.Name
+		}
+	}
+	t.name("const").s(" securitySchemes: Record<string, SecurityScheme>").assign().s(string(must(json.Marshal(schemes)))).newline().newline()
+
+	operations := map[string][][]string{}
+	for route, path := range api.Paths.Iterate() {
+		for method, operation := range path.IterateOperations() {
+			if len(operation.Security) == 0 {
+				continue
+			}
+			requirements := [][]string{}
+			for _, requirement := range operation.Security {
+				requirements = append(requirements, slices.Sorted(maps.Keys(requirement)))
+			}
+			operations[method+" "+route] = requirements
+		}
+	}
+	t.name("const").s(" operationSecurity: Record<string, string[][]>").assign().s(string(must(json.Marshal(operations)))).newline()
+}
+
+func operationDoc(operation *openapi.Operation) []string {
+	lines := []string{}
+	if operation.Summary != "" {
+		lines = append(lines, operation.Summary)
+	}
+	if operation.Description != "" {
+		lines = append(lines, strings.Split(operation.Description, "\n")...)
+	}
+	if operation.Deprecated {
+		lines = append(lines, "@deprecated")
+	}
+	return append(lines, securityDoc(operation.Security)...)
+}
+
+func securityDoc(security []openapi.SecurityRequirement) []string {
+	lines := []string{}
+	for _, requirement := range security {
+		schemes := []string{}
+		for _, name := range slices.Sorted(maps.Keys(requirement)) {
+			if scopes := requirement[name]; len(scopes) > 0 {
+				schemes = append(schemes, name+" ("+strings.Join(scopes, ", ")+")")
+			} else {
+				schemes = append(schemes, name)
+			}
+		}
+		lines = append(lines, "@security "+strings.Join(schemes, " & "))
+	}
+	return lines
+}
+
+func writeServers(t *tsGenerator, api openapi.OpenAPI) {
+	defaultServerUrl := ""
+	if len(api.Servers) > 0 {
+		defaultServerUrl = strings.TrimSuffix(api.Servers[0].ExpandedUrl(), "/")
+	}
+	t.newline().name("const").s(" defaultServerUrl").assign().s(string(must(json.Marshal(defaultServerUrl)))).newline()
+}
+
+func writeMultipart(t *tsGenerator, api openapi.OpenAPI) {
+	operations := []string{}
+	for route, path := range api.Paths.Iterate() {
+		for method, operation := range path.IterateOperations() {
+			if operation.RequestBody == nil {
+				continue
+			}
+			if _, ok := operation.RequestBody.Content["multipart/form-data"]; ok {
+				operations = append(operations, method+" "+route)
+			}
+		}
+	}
+	t.newline().name("const").s(" multipartOperations: string[]").assign().s(string(must(json.Marshal(operations)))).newline()
+}
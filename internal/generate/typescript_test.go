@@ -1,6 +1,7 @@
 package generate_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -117,3 +118,73 @@ func TestGenerateTypescriptModels(t *testing.T) {
 	})
 	t.Log(string(data))
 }
+
+type Status string
+
+func (Status) Enum() []any { return []any{Status("active"), Status("it's")} }
+
+func TestGenerateTypescriptEnums(t *testing.T) {
+	schema := openapi.ValueToSchema(struct {
+		Status Status  `json:"status"`
+		Levels []int   `json:"levels" validate:"enum=1|2"`
+		Filter *Status `json:"filter"`
+	}{})
+	data := string(generate.GenerateTypescriptModels(openapi.OpenAPI{
+		Info:       openapi.Info{Title: "DemoApi"},
+		Paths:      openapi.Paths{},
+		Components: openapi.Components{Schemas: map[string]openapi.Schema{"Account": *schema}},
+	}))
+	for _, expected := range []string{
+		`status: 'active' | 'it\'s';`,
+		`levels: (1 | 2)[] | null;`,
+		`filter: 'active' | 'it\'s' | null;`,
+	} {
+		if !strings.Contains(data, expected) {
+			t.Errorf("expected %v in\n%v", expected, data)
+		}
+	}
+}
+
+func TestGenerateTypescriptEscapesDocComments(t *testing.T) {
+	paths := openapi.Paths{}
+	paths.Set("/files", openapi.PathItem{Get: &openapi.Operation{
+		Summary:     "List files */ alert(1)",
+		Description: "Matches globs like src/**/*.go",
+	}})
+	data := string(generate.GenerateTypescriptModels(openapi.OpenAPI{
+		Info:  openapi.Info{Title: "Demo */ Api"},
+		Paths: paths,
+	}))
+	for _, expected := range []string{`Demo *\/ Api`, `List files *\/ alert(1)`, `Matches globs like src/**\/*.go`} {
+		if !strings.Contains(data, expected) {
+			t.Errorf("expected %v in\n%v", expected, data)
+		}
+	}
+	if strings.Contains(data, "*/ alert(1)") {
+		t.Errorf("expected no doc comment to end early in\n%v", data)
+	}
+}
+
+func TestGenerateTypescriptLeavesCookiesToTheBrowser(t *testing.T) {
+	paths := openapi.Paths{}
+	paths.Set("/me", openapi.PathItem{Get: &openapi.Operation{
+		Security: []openapi.SecurityRequirement{{"session": {}}},
+	}})
+	data := string(generate.GenerateTypescriptModels(openapi.OpenAPI{
+		Info:  openapi.Info{Title: "DemoApi"},
+		Paths: paths,
+		Components: openapi.Components{SecuritySchemes: map[string]openapi.SecurityScheme{
+			"session": {Type: "apiKey", In: "cookie", Name: "sid"},
+			"token":   {Type: "http", Scheme: "bearer"},
+		}},
+	}))
+	if strings.Contains(data, "headers['Cookie']") {
+		t.Errorf("expected the client not to set the forbidden Cookie header in\n%v", data)
+	}
+	if strings.Contains(data, "session?: string") || !strings.Contains(data, "token?: string") {
+		t.Errorf("expected only credentials which aren't cookies in\n%v", data)
+	}
+	if !strings.Contains(data, "credentials: usesCookies(api.method + ' ' + api.path) ? 'include' : 'same-origin'") {
+		t.Errorf("expected fetch to include cookies for cookie schemes in\n%v", data)
+	}
+}
@@ -0,0 +1,271 @@
+package openapi
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Constrain adds the constraints of a validate tag to the schema. The tag is
+// a comma separated list of keywords and their values:
+//
+//	min=1,max=100,multipleOf=5  bounds of numbers
+//	minLength=1,maxLength=64    bounds of the length of strings
+//	minItems=1,maxItems=10      bounds of the length of arrays
+//	format=email                a format like date-time, date, email, uuid, uri, ipv4 or ipv6
+//	enum=draft|published        the allowed values separated by |
+//	pattern=^[a-z]+$            a regular expression, it takes the rest of the tag
+//	required                    the value must not be null
+//
+// The constraints of arrays other than minItems and maxItems apply to their
+// items.
+func (s *Schema) Constrain(tag string) error {
+	for tag = strings.TrimSpace(tag); tag != ""; tag = strings.TrimSpace(tag) {
+		var constraint string
+		if strings.HasPrefix(tag, "pattern=") {
+			constraint, tag = tag, ""
+		} else {
+			constraint, tag, _ = strings.Cut(tag, ",")
+		}
+		keyword, value, ok := strings.Cut(strings.TrimSpace(constraint), "=")
+		if keyword == "required" && !ok {
+			s.Nullable = false
+			continue
+		}
+		if !ok || value == "" {
+			return fmt.Errorf("constraint %q has no value", constraint)
+		}
+		target := s
+		if s.Type == "array" && s.Items != nil && keyword != "minItems" && keyword != "maxItems" {
+			target = s.Items
+		}
+		var err error
+		switch keyword {
+		case "min":
+			target.Minimum, err = parseConstraint(value, parseFloat)
+		case "max":
+			target.Maximum, err = parseConstraint(value, parseFloat)
+		case "multipleOf":
+			target.MultipleOf, err = parseConstraint(value, parseFloat)
+			if err == nil && *target.MultipleOf <= 0 {
+				err = errors.New("must be greater than 0")
+			}
+		case "minLength":
+			target.MinLength, err = parseConstraint(value, strconv.Atoi)
+		case "maxLength":
+			target.MaxLength, err = parseConstraint(value, strconv.Atoi)
+		case "minItems":
+			target.MinItems, err = parseConstraint(value, strconv.Atoi)
+		case "maxItems":
+			target.MaxItems, err = parseConstraint(value, strconv.Atoi)
+		case "pattern":
+			_, err = regexp.Compile(value)
+			target.Pattern = value
+		case "format":
+			target.Format, target.constrainedFormat = value, true
+		case "enum":
+			target.Enum, err = target.enumValues(strings.Split(value, "|"))
+		default:
+			return fmt.Errorf("unknown constraint %q", keyword)
+		}
+		if err != nil {
+			return fmt.Errorf("constraint %v: %w", keyword, err)
+		}
+	}
+	return nil
+}
+
+// Constrained reports whether the schema, its properties or its items have
+// constraints. Formats only count if they were set by the validate tag.
+func (s *Schema) Constrained() bool {
+	if s == nil {
+		return false
+	}
+	if s.Minimum != nil || s.Maximum != nil || s.MultipleOf != nil || s.MinLength != nil || s.MaxLength != nil ||
+		s.MinItems != nil || s.MaxItems != nil || s.Pattern != "" || len(s.Enum) > 0 || s.constrainedFormat && formats[s.Format] != nil {
+		return true
+	}
+	for _, property := range s.Properties {
+		if property.Constrained() {
+			return true
+		}
+	}
+	return s.Items.Constrained() || s.AdditionalProperties.Constrained()
+}
+
+func parseConstraint[T any](value string, parse func(string) (T, error)) (*T, error) {
+	v, err := parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func parseFloat(value string) (float64, error) {
+	return strconv.ParseFloat(value, 64)
+}
+
+// enumValues parses the values according to the type of the schema
+func (s *Schema) enumValues(values []string) ([]any, error) {
+	enum := make([]any, len(values))
+	for i, value := range values {
+		var err error
+		switch s.Type {
+		case "integer":
+			enum[i], err = strconv.ParseInt(value, 10, 64)
+		case "number":
+			enum[i], err = strconv.ParseFloat(value, 64)
+		case "boolean":
+			enum[i], err = strconv.ParseBool(value)
+		default:
+			enum[i] = value
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return enum, nil
+}
+
+func (s *Schema) validateNumber(n float64, location string) []Violation {
+	violations := []Violation{}
+	if s.Minimum != nil && n < *s.Minimum {
+		violations = append(violations, violation(location, "must be at least "+formatFloat(*s.Minimum)))
+	}
+	if s.Maximum != nil && n > *s.Maximum {
+		violations = append(violations, violation(location, "must be at most "+formatFloat(*s.Maximum)))
+	}
+	if s.MultipleOf != nil {
+		if q := n / *s.MultipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+			violations = append(violations, violation(location, "must be a multiple of "+formatFloat(*s.MultipleOf)))
+		}
+	}
+	return append(violations, s.validateEnum(n, location)...)
+}
+
+func (s *Schema) validateString(value string, location string) []Violation {
+	violations := []Violation{}
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		violations = append(violations, violation(location, fmt.Sprintf("must be at least %v characters long", *s.MinLength)))
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		violations = append(violations, violation(location, fmt.Sprintf("must be at most %v characters long", *s.MaxLength)))
+	}
+	if s.Pattern != "" {
+		if pattern, err := compilePattern(s.Pattern); err == nil && !pattern.MatchString(value) {
+			violations = append(violations, violation(location, "must match the pattern "+s.Pattern))
+		}
+	}
+	if valid, ok := formats[s.Format]; ok && !valid(value) {
+		violations = append(violations, violation(location, "must be a valid "+s.Format))
+	}
+	return append(violations, s.validateEnum(value, location)...)
+}
+
+func (s *Schema) validateItems(length int, location string) []Violation {
+	violations := []Violation{}
+	if s.MinItems != nil && length < *s.MinItems {
+		violations = append(violations, violation(location, fmt.Sprintf("must contain at least %v items", *s.MinItems)))
+	}
+	if s.MaxItems != nil && length > *s.MaxItems {
+		violations = append(violations, violation(location, fmt.Sprintf("must contain at most %v items", *s.MaxItems)))
+	}
+	return violations
+}
+
+// validateEnum checks strings, numbers and booleans against the enum, values
+// are compared by their kind so named types like type Status string match
+func (s *Schema) validateEnum(value any, location string) []Violation {
+	if len(s.Enum) == 0 {
+		return nil
+	}
+	for _, allowed := range s.Enum {
+		if enumValue(allowed) == value {
+			return nil
+		}
+	}
+	return []Violation{violation(location, fmt.Sprintf("must be one of %v", s.Enum))}
+}
+
+func enumValue(value any) any {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	return value
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// patterns caches the compiled patterns by their source
+var patterns sync.Map
+
+func compilePattern(source string) (*regexp.Regexp, error) {
+	if pattern, ok := patterns.Load(source); ok {
+		return pattern.(*regexp.Regexp), nil
+	}
+	pattern, err := regexp.Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(source, pattern)
+	return pattern, nil
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// formats are the validated formats, others are only documented
+var formats = map[string]func(string) bool{
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+	"date": func(s string) bool {
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	},
+	"email": func(s string) bool {
+		address, err := mail.ParseAddress(s)
+		return err == nil && address.Address == s
+	},
+	"uuid": uuidPattern.MatchString,
+	"uri": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	},
+	"ipv4": func(s string) bool {
+		addr, err := netip.ParseAddr(s)
+		return err == nil && addr.Is4()
+	},
+	"ipv6": func(s string) bool {
+		addr, err := netip.ParseAddr(s)
+		return err == nil && addr.Is6()
+	},
+	"byte": func(s string) bool {
+		_, err := base64.StdEncoding.DecodeString(s)
+		return err == nil
+	},
+}
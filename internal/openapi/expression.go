@@ -0,0 +1,152 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RuntimeContext holds the values runtime expressions are resolved against,
+// bodies are JSON encoded
+type RuntimeContext struct {
+	Request        *http.Request
+	RequestBody    []byte
+	StatusCode     int
+	ResponseHeader http.Header
+	ResponseBody   []byte
+}
+
+// ResolveExpression resolves a runtime expression like $request.query.id or a
+// template embedding expressions in braces like
+// {$request.body#/callbackUrl}/events
+func ResolveExpression(expression string, c RuntimeContext) (string, error) {
+	if strings.HasPrefix(expression, "$") {
+		return c.resolve(expression)
+	}
+	b := strings.Builder{}
+	for {
+		start := strings.Index(expression, "{$")
+		if start < 0 {
+			b.WriteString(expression)
+			return b.String(), nil
+		}
+		end := strings.Index(expression[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated expression in %q", expression)
+		}
+		value, err := c.resolve(expression[start+1 : start+end])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(expression[:start])
+		b.WriteString(value)
+		expression = expression[start+end+1:]
+	}
+}
+
+func (c RuntimeContext) resolve(expression string) (string, error) {
+	switch expression {
+	case "$url":
+		if c.Request == nil {
+			return "", fmt.Errorf("%v needs a request", expression)
+		}
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		return scheme + "://" + c.Request.Host + c.Request.URL.RequestURI(), nil
+	case "$method":
+		if c.Request == nil {
+			return "", fmt.Errorf("%v needs a request", expression)
+		}
+		return c.Request.Method, nil
+	case "$statusCode":
+		return strconv.Itoa(c.StatusCode), nil
+	}
+
+	source, rest, _ := strings.Cut(strings.TrimPrefix(expression, "$"), ".")
+	var header http.Header
+	var body []byte
+	switch source {
+	case "request":
+		if c.Request == nil {
+			return "", fmt.Errorf("%v needs a request", expression)
+		}
+		header, body = c.Request.Header, c.RequestBody
+	case "response":
+		header, body = c.ResponseHeader, c.ResponseBody
+	default:
+		return "", fmt.Errorf("unknown runtime expression %q", expression)
+	}
+
+	if pointer, ok := strings.CutPrefix(rest, "body"); ok {
+		if pointer != "" && !strings.HasPrefix(pointer, "#") {
+			return "", fmt.Errorf("unknown runtime expression %q", expression)
+		}
+		return resolvePointer(body, strings.TrimPrefix(pointer, "#"))
+	}
+	in, name, ok := strings.Cut(rest, ".")
+	if !ok || name == "" {
+		return "", fmt.Errorf("unknown runtime expression %q", expression)
+	}
+	switch {
+	case in == "header":
+		if value := header.Get(name); value != "" {
+			return value, nil
+		}
+	case in == "query" && source == "request":
+		if values, ok := c.Request.URL.Query()[name]; ok {
+			return values[0], nil
+		}
+	case in == "path" && source == "request":
+		if value := c.Request.PathValue(name); value != "" {
+			return value, nil
+		}
+	default:
+		return "", fmt.Errorf("unknown runtime expression %q", expression)
+	}
+	return "", fmt.Errorf("%v is not set", expression)
+}
+
+// resolvePointer resolves the JSON pointer (RFC 6901) in the JSON document,
+// strings are returned as they are and other values JSON encoded
+func resolvePointer(document []byte, pointer string) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("body is no JSON: %w", err)
+	}
+	if pointer != "" {
+		if !strings.HasPrefix(pointer, "/") {
+			return "", fmt.Errorf("invalid JSON pointer %q", pointer)
+		}
+		for _, token := range strings.Split(pointer[1:], "/") {
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			switch v := value.(type) {
+			case map[string]any:
+				next, ok := v[token]
+				if !ok {
+					return "", fmt.Errorf("%v does not exist", pointer)
+				}
+				value = next
+			case []any:
+				i, err := strconv.Atoi(token)
+				if err != nil || i < 0 || i >= len(v) {
+					return "", fmt.Errorf("%v does not exist", pointer)
+				}
+				value = v[i]
+			default:
+				return "", fmt.Errorf("%v does not exist", pointer)
+			}
+		}
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(value)
+	return string(b), err
+}
@@ -0,0 +1,53 @@
+package openapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Instantan/web/internal/openapi"
+)
+
+func TestResolveExpression(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/subscriptions/7?topic=orders", nil)
+	r.SetPathValue("id", "7")
+	r.Header.Set("X-Callback", "https://client.example.com/hook")
+	c := openapi.RuntimeContext{
+		Request:        r,
+		RequestBody:    []byte(`{"callbackUrl":"https://client.example.com/cb","ids":[10,20],"a/b":{"n":1000000}}`),
+		StatusCode:     http.StatusCreated,
+		ResponseHeader: http.Header{"Location": {"/subscriptions/7"}},
+		ResponseBody:   []byte(`{"id":"sub_1"}`),
+	}
+
+	tests := map[string]string{
+		"$url":                                "http://example.com/subscriptions/7?topic=orders",
+		"$method":                             "POST",
+		"$statusCode":                         "201",
+		"$request.path.id":                    "7",
+		"$request.query.topic":                "orders",
+		"$request.header.x-callback":          "https://client.example.com/hook",
+		"$request.body#/callbackUrl":          "https://client.example.com/cb",
+		"$request.body#/ids/1":                "20",
+		"$request.body#/a~1b":                 `{"n":1000000}`,
+		"$response.header.Location":           "/subscriptions/7",
+		"{$request.body#/callbackUrl}/orders": "https://client.example.com/cb/orders",
+		"{$request.body#/callbackUrl}?id={$response.body#/id}": "https://client.example.com/cb?id=sub_1",
+	}
+	for expression, expected := range tests {
+		value, err := openapi.ResolveExpression(expression, c)
+		if err != nil {
+			t.Errorf("%v: %v", expression, err)
+			continue
+		}
+		if value != expected {
+			t.Errorf("%v: expected %q, got %q", expression, expected, value)
+		}
+	}
+
+	for _, expression := range []string{"$request.body#/missing", "$request.query.missing", "$request.cookie.x", "$unknown", "{$request.body#/callbackUrl"} {
+		if value, err := openapi.ResolveExpression(expression, c); err == nil {
+			t.Errorf("%v: expected an error, got %q", expression, value)
+		}
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"reflect"
+	"slices"
+	"strings"
 )
 
 // This is the root object of the OpenAPI document.
@@ -32,7 +35,7 @@ type OpenAPI struct {
 	// refer to each webhook, while the (optionally referenced) Path Item Object
 	// describes a request that may be initiated by the API provider and the expected
 	// responses. An example is available.
-	Webhooks map[string]PathItem/*Reference*/ `json:"webooks,omitempty"`
+	Webhooks map[string]PathItem/*Reference*/ `json:"webhooks,omitempty"`
 	// An element to hold various schemas for the document.
 	Components Components `json:"components,omitempty"`
 	// A declaration of which security mechanisms can be used across the API. The list
@@ -40,7 +43,7 @@ type OpenAPI struct {
 	// Only one of the security requirement objects need to be satisfied to authorize
 	// a request. Individual operations can override this definition. To make security
 	//optional, an empty security requirement ({}) can be included in the array.
-	Security SecurityRequirement `json:"security,omitempty"`
+	Security []SecurityRequirement `json:"security,omitempty"`
 	// A list of tags used by the document with additional metadata. The order of the
 	// tags can be used to reflect on their order by the parsing tools. Not all tags
 	// that are used by the Operation Object must be declared. The tags that are not
@@ -114,7 +117,7 @@ type ServerVariable struct {
 	// Schema Object’s treatment of default values, because in those cases parameter
 	// values are optional. If the enum is defined, the value MUST exist in the enum’s
 	// values.
-	Default string `json:""`
+	Default string `json:"default"`
 	// An optional description for the server variable. [CommonMark] syntax MAY be used
 	// for rich text representation.
 	Description string `json:"description,omitempty"`
@@ -211,10 +214,21 @@ type Operation struct {
 	// To make security optional, an empty security requirement ({}) can be included in the
 	// array. This definition overrides any declared top-level security. To remove a top-level
 	// security declaration, an empty array can be used.
-	Security SecurityRequirement `json:"security"`
+	Security []SecurityRequirement `json:"security,omitempty"`
 	// An alternative server array to service this operation. If an alternative server object
 	// is specified at the Path Item Object or Root level, it will be overridden by this value.
-	Servers []Server `json:"server"`
+	Servers []Server `json:"servers,omitempty"`
+	// The messages exchanged over the websocket this operation upgrades to. OpenAPI
+	// cannot describe them, they are documented as specification extension.
+	WebSocket *WebSocket `json:"x-websocket,omitempty"`
+}
+
+// WebSocket describes the messages of a websocket from the perspective of the server
+type WebSocket struct {
+	// The schema of the messages received from the client.
+	Receive *Schema `json:"receive,omitempty"`
+	// The schema of the messages sent to the client.
+	Send *Schema `json:"send,omitempty"`
 }
 
 type RequestBody struct {
@@ -246,7 +260,7 @@ type MediaType struct {
 	// A map between a property name and its encoding information. The key, being the property
 	// name, MUST exist in the schema as a property. The encoding object SHALL only apply to
 	// requestBody objects when the media type is multipart or application/x-www-form-urlencoded.
-	Encoding map[string]Encoding `json:"encoding"`
+	Encoding map[string]Encoding `json:"encoding,omitempty"`
 }
 
 type Encoding struct {
@@ -255,7 +269,7 @@ type Encoding struct {
 	// inner type; for all other cases the default is application/octet-stream. The value can
 	// be a specific media type (e.g. application/json), a wildcard media type (e.g. image/*),
 	// or a comma-separated list of the two types.
-	ContentType string `json:"contentType"`
+	ContentType string `json:"contentType,omitempty"`
 	// A map allowing additional information to be provided as headers, for example
 	// Content-Disposition. Content-Type is described separately and SHALL be ignored in
 	// this section. This property SHALL be ignored if the request body media type is not
@@ -266,7 +280,7 @@ type Encoding struct {
 	// values as query parameters, including default values. This property SHALL be ignored
 	// if the request body media type is not application/x-www-form-urlencoded or multipart/form-data.
 	// If a value is explicitly defined, then the value of contentType (implicit or explicit) SHALL be ignored.
-	Style string `json:"style"`
+	Style string `json:"style,omitempty"`
 	// When this is true, property values of type array or object generate separate parameters
 	// for each value of the array, or key-value-pair of the map. For other types of properties
 	// this property has no effect. When style is form, the default value is true. For all other
@@ -274,14 +288,14 @@ type Encoding struct {
 	// media type is not application/x-www-form-urlencoded or multipart/form-data. If a value
 	// is explicitly defined, then the value of contentType (implicit or explicit) SHALL be
 	// ignored.
-	Explode bool `json:"explode"`
+	Explode bool `json:"explode,omitempty"`
 	// Determines whether the parameter value SHOULD allow reserved characters, as defined
 	// by [RFC3986] Section 2.2 :/?#[]@!$&'()*+,;= to be included without percent-encoding.
 	// The default value is false. This property SHALL be ignored if the request body media
 	// type is not application/x-www-form-urlencoded or multipart/form-data. If a value is
 	// explicitly defined, then the value of contentType (implicit or explicit) SHALL be
 	// ignored.
-	AllowReserved bool
+	AllowReserved bool `json:"allowReserved,omitempty"`
 }
 
 type Example struct {
@@ -506,6 +520,9 @@ type Components struct {
 	Callbacks map[string]Callback/*Reference*/ `json:"callbacks,omitempty"`
 	// An object to hold reusable Path Item Object.
 	PathItems map[string]PathItem/*Reference*/ `json:"pathItems,omitempty"`
+
+	// names holds the names of the schemas registered by ComponentSchema
+	names map[reflect.Type]string
 }
 
 type SecurityScheme struct {
@@ -516,42 +533,42 @@ type SecurityScheme struct {
 	// text representation.
 	Description string `json:"description,omitempty"`
 	// REQUIRED. The name of the header, query or cookie parameter to be used.
-	Name string `json:"name"`
+	Name string `json:"name,omitempty"`
 	// REQUIRED. The location of the API key. Valid values are "query", "header" or "cookie".
-	In string `json:"in"`
+	In string `json:"in,omitempty"`
 	// REQUIRED. The name of the HTTP Authorization scheme to be used in the Authorization
 	// header as defined in [RFC7235] Section 5.1. The values used SHOULD be registered
 	// in the IANA Authentication Scheme registry.
-	Scheme string `json:"scheme"`
+	Scheme string `json:"scheme,omitempty"`
 	// A hint to the client to identify how the bearer token is formatted. Bearer tokens are
 	// usually generated by an authorization server, so this information is primarily for
 	// documentation purposes.
 	BearerFormat string `json:"bearerFormat,omitempty"`
 	// REQUIRED. An object containing configuration information for the flow types supported.
-	Flows OAuthFlows `json:"flows"`
+	Flows *OAuthFlows `json:"flows,omitempty"`
 	// REQUIRED. OpenId Connect URL to discover OAuth2 configuration values. This MUST be in
 	// the form of a URL. The OpenID Connect standard requires the use of TLS.
-	OpenIdConnectUrl string `json:"openIdConnect"`
+	OpenIdConnectUrl string `json:"openIdConnectUrl,omitempty"`
 }
 
 type OAuthFlows struct {
 	// Configuration for the OAuth Implicit flow
-	Implicit OAuthFlow `json:"implicit,omitempty"`
+	Implicit *OAuthFlow `json:"implicit,omitempty"`
 	// Configuration for the OAuth Resource Owner Password flow
-	Password OAuthFlow `json:"password,omitempty"`
+	Password *OAuthFlow `json:"password,omitempty"`
 	// Configuration for the OAuth Client Credentials flow. Previously called application in OpenAPI 2.0.
-	ClientCredentials OAuthFlow `json:"clientCredentials,omitempty"`
+	ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty"`
 	// Configuration for the OAuth Authorization Code flow. Previously called accessCode in OpenAPI 2.0.
-	AuthorizationCode OAuthFlow `json:"authorizationCode,omitempty"`
+	AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty"`
 }
 
 type OAuthFlow struct {
 	// REQUIRED. The authorization URL to be used for this flow. This MUST be in
 	// the form of a URL. The OAuth2 standard requires the use of TLS.
-	AuthorizationUrl string `json:"authorizationUrl"`
+	AuthorizationUrl string `json:"authorizationUrl,omitempty"`
 	// REQUIRED. The token URL to be used for this flow. This MUST be in the form of a URL.
 	// The OAuth2 standard requires the use of TLS.
-	TokenUrl string `json:"tokenUrl"`
+	TokenUrl string `json:"tokenUrl,omitempty"`
 	// The URL to be used for obtaining refresh tokens. This MUST be in the form of a URL.
 	// The OAuth2 standard requires the use of TLS.
 	RefreshUrl string `json:"refreshUrl,omitempty"`
@@ -597,6 +614,15 @@ func (u Responses) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m)
 }
 
+// ExpandedUrl returns the url with every variable replaced by its default
+func (s Server) ExpandedUrl() string {
+	url := s.Url
+	for name, variable := range s.Variables {
+		url = strings.ReplaceAll(url, "{"+name+"}", variable.Default)
+	}
+	return url
+}
+
 func (u OpenAPI) MarshalJSON() ([]byte, error) {
 	if err := errors.Join(
 		requireOpenAPIField("Info.Title", u.Info.Title),
@@ -612,10 +638,22 @@ func (m Schema) MarshalJSON() ([]byte, error) {
 	if m.Ref != "" {
 		n := map[string]any{}
 		n["$ref"] = m.Ref
+		if m.Nullable {
+			return json.Marshal(map[string]any{"anyOf": []any{n, map[string]any{"type": "null"}}})
+		}
 		return json.Marshal(n)
 	}
 	type Alias Schema
-	return json.Marshal(Alias(m))
+	if !m.Nullable || m.Type == "" {
+		return json.Marshal(Alias(m))
+	}
+	if len(m.Enum) > 0 {
+		m.Enum = append(slices.Clip(m.Enum), nil)
+	}
+	return json.Marshal(struct {
+		Type []string `json:"type"`
+		Alias
+	}{[]string{m.Type, "null"}, Alias(m)})
 }
 
 func (p PathItem) IterateOperations() func(func(string, *Operation) bool) {
@@ -641,7 +679,7 @@ func (p PathItem) IterateOperations() func(func(string, *Operation) bool) {
 			}
 		}
 		if p.Post != nil {
-			if !yield(http.MethodPatch, p.Post) {
+			if !yield(http.MethodPost, p.Post) {
 				return
 			}
 		}
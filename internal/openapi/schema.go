@@ -1,24 +1,80 @@
 package openapi
 
 import (
+	"encoding"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 )
 
 type Schema struct {
-	Type       string             `json:"type"`
+	// Type is empty for schemas accepting any value
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
 	Required   []string           `json:"required,omitempty"`
 	Properties map[string]*Schema `json:"properties,omitempty"`
-	Items      *Schema            `json:"items,omitempty"`
-	Example    any                `json:"example,omitempty"`
-	TypeName   string             `json:"-"`
+	// AdditionalProperties describes the values of maps
+	AdditionalProperties *Schema `json:"additionalProperties,omitempty"`
+	Items                *Schema `json:"items,omitempty"`
+	// Constraints set by the validate tag, see Constrain
+	Minimum    *float64 `json:"minimum,omitempty"`
+	Maximum    *float64 `json:"maximum,omitempty"`
+	MultipleOf *float64 `json:"multipleOf,omitempty"`
+	MinLength  *int     `json:"minLength,omitempty"`
+	MaxLength  *int     `json:"maxLength,omitempty"`
+	Pattern    string   `json:"pattern,omitempty"`
+	MinItems   *int     `json:"minItems,omitempty"`
+	MaxItems   *int     `json:"maxItems,omitempty"`
+	Enum       []any    `json:"enum,omitempty"`
+	Example    any      `json:"example,omitempty"`
+	Deprecated bool     `json:"deprecated,omitempty"`
+	TypeName   string   `json:"-"`
+	// Nullable allows null besides the type, it is written as type [Type, null]
+	// or as anyOf the reference and null
+	Nullable bool `json:"-"`
+	// constrainedFormat reports whether the format was set by the validate
+	// tag rather than implied by the type, like date-time of time.Time
+	constrainedFormat bool
 	// Reference to schema, if its set the the schema wont get displayed directly
 	Ref string `json:"$ref,omitempty"`
 }
 
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	enumerType        = reflect.TypeFor[Enumer]()
+)
+
+// Enumer is implemented by types with a fixed set of values, e.g. the
+// constants of type Status string. The values are documented as enum and
+// others are rejected.
+type Enumer interface {
+	Enum() []any
+}
+
+// ValueToSchema describes the type of the value, the value itself is only
+// used as example. A nil value accepts anything.
 func ValueToSchema(value any) *Schema {
-	return generateSchema(value)
+	if value == nil {
+		return &Schema{}
+	}
+	return newSchemaGenerator(nil).generate(reflect.TypeOf(value), reflect.ValueOf(value))
+}
+
+// TypeToSchema describes the type without example
+func TypeToSchema(t reflect.Type) *Schema {
+	return newSchemaGenerator(nil).generate(t, reflect.Value{})
+}
+
+// ComponentSchema describes the value like ValueToSchema, but every named
+// struct is added to the schemas of the components once and referenced
+func ComponentSchema(value any, components *Components) Schema {
+	if value == nil {
+		return Schema{}
+	}
+	return *newSchemaGenerator(components).generate(reflect.TypeOf(value), reflect.ValueOf(value))
 }
 
 func valuesToSchemas(values []any) []Schema {
@@ -29,84 +85,199 @@ func valuesToSchemas(values []any) []Schema {
 	return schemas
 }
 
-func generateSchema(value any) *Schema {
-	if value == nil {
-		return &Schema{Type: "null"}
+type schemaGenerator struct {
+	// components collects the named structs, without components they are
+	// inlined
+	components *Components
+	// visiting holds the structs being described to end recursion
+	visiting map[reflect.Type]bool
+}
+
+func newSchemaGenerator(components *Components) *schemaGenerator {
+	return &schemaGenerator{
+		components: components,
+		visiting:   map[reflect.Type]bool{},
 	}
+}
 
-	s := schemaForWellKnownTypes(value)
-	if s != nil {
-		return s
+// generate describes t, the value v is optional and only fills the examples.
+// Recursive structs are referenced, or described as plain objects when they
+// are inlined.
+func (g *schemaGenerator) generate(t reflect.Type, v reflect.Value) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		if v.IsValid() {
+			v = v.Elem()
+		}
+	}
+	schema := g.generateType(t, v)
+	if enum := enumOf(t); len(enum) > 0 && schema.Ref == "" {
+		schema.Enum = enum
 	}
+	return schema
+}
 
-	t := reflect.TypeOf(value)
-	v := reflect.ValueOf(value)
+// enumOf returns the values of types implementing Enumer
+func enumOf(t reflect.Type) []any {
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+	if t.Implements(enumerType) {
+		return reflect.Zero(t).Interface().(Enumer).Enum()
+	}
+	if reflect.PointerTo(t).Implements(enumerType) {
+		return reflect.New(t).Interface().(Enumer).Enum()
+	}
+	return nil
+}
+
+func (g *schemaGenerator) generateType(t reflect.Type, v reflect.Value) *Schema {
+	var example any
+	if v.IsValid() && v.CanInterface() {
+		example = v.Interface()
+	}
+
+	if s := schemaForWellKnownTypes(t, example); s != nil {
+		return s
+	}
+	if t.Kind() != reflect.Interface && !t.Implements(textMarshalerType) && !reflect.PointerTo(t).Implements(textMarshalerType) &&
+		(t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType)) {
+		// the encoding is up to the type
+		return &Schema{Example: example}
+	}
+	if t.Kind() != reflect.Interface && (t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)) {
+		return &Schema{Type: "string", Example: textExample(example)}
+	}
 
 	switch t.Kind() {
 	case reflect.Bool:
-		return &Schema{Type: "boolean", Example: value}
+		return &Schema{Type: "boolean", Example: example}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return &Schema{Type: "integer", Example: value}
+		return &Schema{Type: "integer", Example: example}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return &Schema{Type: "integer", Example: value}
+		return &Schema{Type: "integer", Example: example}
 	case reflect.Float32, reflect.Float64:
-		return &Schema{Type: "number", Example: value}
+		return &Schema{Type: "number", Example: example}
 	case reflect.String:
-		return &Schema{Type: "string", Example: value}
+		return &Schema{Type: "string", Example: example}
 	case reflect.Slice, reflect.Array:
-		var itemsSchema *Schema
-		length := v.Len()
-		if length > 0 {
-			itemsSchema = generateSchema(v.Index(0).Interface())
-		} else {
-			elemType := t.Elem()
-			zeroValue := reflect.Zero(elemType).Interface()
-			itemsSchema = generateSchema(zeroValue)
-		}
-		return &Schema{Type: "array", Items: itemsSchema, Example: value}
-	case reflect.Map:
-		schema := &Schema{Type: "object", Properties: make(map[string]*Schema), Example: value}
-		for _, key := range v.MapKeys() {
-			propName := fmt.Sprintf("%v", key.Interface())
-			propValue := v.MapIndex(key).Interface()
-			schema.Properties[propName] = generateSchema(propValue)
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			// encoding/json encodes byte slices as base64
+			return &Schema{Type: "string", Format: "byte"}
 		}
-		return schema
+		var item reflect.Value
+		if v.IsValid() && v.Len() > 0 {
+			item = v.Index(0)
+		}
+		return &Schema{Type: "array", Items: g.generate(t.Elem(), item), Example: example}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.generate(t.Elem(), reflect.Value{}), Example: example}
 	case reflect.Struct:
-		schema := &Schema{Type: "object", Properties: make(map[string]*Schema), Example: value, TypeName: t.Name()}
-		for i := 0; i < t.NumField(); i++ {
-			field := t.Field(i)
-			fieldValue := v.Field(i)
-
-			if !fieldValue.CanInterface() {
-				continue
+		if g.components != nil && t.Name() != "" {
+			name, named := g.components.componentName(t)
+			ref := &Schema{Ref: "#/components/schemas/" + name, TypeName: name}
+			if !named {
+				if g.components.Schemas == nil {
+					g.components.Schemas = map[string]Schema{}
+				}
+				g.components.Schemas[name] = *g.generateStruct(t, v, example, name)
 			}
+			return ref
+		}
+		if g.visiting[t] {
+			return &Schema{Type: "object"}
+		}
+		return g.generateStruct(t, v, example, schemaName(t))
+	case reflect.Interface:
+		return &Schema{Example: example}
+	default:
+		return &Schema{Type: "string", Example: fmt.Sprintf("%v", example)}
+	}
+}
 
-			fieldName := field.Name
-			jsonTag := field.Tag.Get("json")
-			if jsonTag != "" {
-				fieldName = strings.Split(jsonTag, ",")[0]
-				if fieldName == "-" {
-					continue
+func (g *schemaGenerator) generateStruct(t reflect.Type, v reflect.Value, example any, name string) *Schema {
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema), Example: example, TypeName: name}
+	g.addFields(schema, t, v)
+	return schema
+}
+
+// addFields adds the fields of the struct like encoding/json encodes them,
+// fields of embedded structs without name are promoted
+func (g *schemaGenerator) addFields(schema *Schema, t reflect.Type, v reflect.Value) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		var fieldValue reflect.Value
+		if v.IsValid() {
+			fieldValue = v.Field(i)
+		}
+
+		jsonTag := field.Tag.Get("json")
+		fieldName, options, _ := strings.Cut(jsonTag, ",")
+		if fieldName == "-" && options == "" {
+			continue
+		}
+		if field.Anonymous && fieldName == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+				if fieldValue.IsValid() {
+					fieldValue = fieldValue.Elem()
 				}
 			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(schema, embedded, fieldValue)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if fieldName == "" {
+			fieldName = field.Name
+		}
 
-			fieldInterface := fieldValue.Interface()
-			schema.Properties[fieldName] = generateSchema(fieldInterface)
-
-			if !strings.Contains(jsonTag, "omitempty") && fieldName != "" {
-				schema.Required = append(schema.Required, fieldName)
+		fieldSchema := g.generate(field.Type, fieldValue)
+		if slices.Contains(strings.Split(options, ","), "string") {
+			fieldSchema = &Schema{Type: "string", Example: fieldSchema.Example}
+		}
+		omitempty := slices.Contains(strings.Split(options, ","), "omitempty")
+		if !omitempty && isNullable(field.Type) {
+			// encoding/json writes nil pointers, slices and maps as null
+			fieldSchema.Nullable = true
+		}
+		if tag, ok := field.Tag.Lookup("validate"); ok {
+			if err := fieldSchema.Constrain(tag); err != nil {
+				panic(fmt.Errorf("%v.%v: %w", t, field.Name, err))
 			}
 		}
-		return schema
-	case reflect.Interface, reflect.Ptr:
-		if v.IsNil() {
-			return &Schema{Type: "null", Example: nil}
+		fieldSchema.Deprecated = field.Tag.Get("deprecated") == "true"
+		schema.Properties[fieldName] = fieldSchema
+
+		if !omitempty {
+			schema.Required = append(schema.Required, fieldName)
+		}
+	}
+}
+
+// isNullable reports whether encoding/json writes null for the zero value of
+// the type
+func isNullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+func textExample(example any) any {
+	if m, ok := example.(encoding.TextMarshaler); ok {
+		if text, err := m.MarshalText(); err == nil {
+			return string(text)
 		}
-		return generateSchema(v.Elem().Interface())
-	default:
-		return &Schema{Type: "string", Example: fmt.Sprintf("%v", value)}
 	}
+	return nil
 }
 
 func (o Operation) schemaOf(in string) Schema {
@@ -122,7 +293,9 @@ func (o Operation) schemaOf(in string) Schema {
 		if param.Required {
 			s.Required = append(s.Required, param.Name)
 		}
-		s.Properties[param.Name] = &param.Schema
+		schema := param.Schema
+		schema.Deprecated = param.Deprecated
+		s.Properties[param.Name] = &schema
 	}
 	return s
 }
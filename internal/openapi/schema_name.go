@@ -0,0 +1,170 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SchemaNamer is implemented by types overriding the name of their component
+// schema
+type SchemaNamer interface {
+	SchemaName() string
+}
+
+var schemaNamerType = reflect.TypeFor[SchemaNamer]()
+
+// componentName returns the name of the component schema of the type. The
+// first free one of its name, its name qualified by the package and its name
+// with a numeric suffix is used, so types with the same name don't overwrite
+// each other. The second result reports whether the type was named before.
+func (c *Components) componentName(t reflect.Type) (string, bool) {
+	if name, ok := c.names[t]; ok {
+		return name, true
+	}
+	if c.names == nil {
+		c.names = map[reflect.Type]string{}
+	}
+	name := schemaName(t)
+	candidate := name
+	for i := 1; c.isTaken(candidate); i++ {
+		if i == 1 {
+			candidate = packageName(t.PkgPath()) + name
+		} else {
+			candidate = name + strconv.Itoa(i)
+		}
+	}
+	c.names[t] = candidate
+	return candidate, false
+}
+
+// isTaken reports whether the name is used by a schema or reserved for a type
+func (c *Components) isTaken(name string) bool {
+	if _, ok := c.Schemas[name]; ok {
+		return true
+	}
+	for _, n := range c.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaName returns the name of the type as identifier, type arguments of
+// generic types are appended like in PageOfUser
+func schemaName(t reflect.Type) string {
+	if t.Implements(schemaNamerType) {
+		if name := reflect.Zero(t).Interface().(SchemaNamer).SchemaName(); name != "" {
+			return name
+		}
+	} else if reflect.PointerTo(t).Implements(schemaNamerType) {
+		if name := reflect.New(t).Interface().(SchemaNamer).SchemaName(); name != "" {
+			return name
+		}
+	}
+	return typeName(t.Name())
+}
+
+// typeName turns a type name as reported by reflect, e.g.
+// Page[github.com/acme/models.User], into an identifier
+func typeName(name string) string {
+	switch {
+	case strings.HasPrefix(name, "*"):
+		return typeName(name[1:])
+	case strings.HasPrefix(name, "[]"):
+		return typeName(name[2:]) + "List"
+	case strings.HasPrefix(name, "["):
+		_, elem, _ := strings.Cut(name, "]")
+		return typeName(elem) + "List"
+	case strings.HasPrefix(name, "map["):
+		_, key, value, _ := splitBracket(name[len("map"):])
+		return "MapOf" + typeName(key) + "To" + typeName(value)
+	}
+	base, args, _, generic := splitBracket(name)
+	if i := strings.LastIndex(base, "/"); i >= 0 {
+		base = base[i+1:]
+	}
+	if i := strings.LastIndex(base, "."); i >= 0 {
+		base = base[i+1:]
+	}
+	base = identifier(base)
+	if !generic {
+		return base
+	}
+	names := []string{}
+	for _, arg := range splitTopLevel(args) {
+		names = append(names, typeName(strings.TrimSpace(arg)))
+	}
+	return base + "Of" + strings.Join(names, "And")
+}
+
+// splitBracket splits name[inner]rest at the first bracket and its match
+func splitBracket(name string) (before string, inner string, after string, found bool) {
+	start := strings.Index(name, "[")
+	if start < 0 {
+		return name, "", "", false
+	}
+	depth := 0
+	for i := start; i < len(name); i++ {
+		switch name[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return name[:start], name[start+1 : i], name[i+1:], true
+			}
+		}
+	}
+	return name[:start], name[start+1:], "", true
+}
+
+// splitTopLevel splits the type arguments at the commas outside of brackets
+func splitTopLevel(args string) []string {
+	parts := []string{}
+	depth, start := 0, 0
+	for i, r := range args {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, args[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, args[start:])
+}
+
+// packageName returns the last element of the package path as identifier,
+// e.g. github.com/acme/billing_v2 becomes BillingV2
+func packageName(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		path = path[i+1:]
+	}
+	return identifier(path)
+}
+
+// identifier drops the runes not allowed in identifiers and upper cases the
+// letters following them and the first letter
+func identifier(s string) string {
+	b := strings.Builder{}
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -2,7 +2,9 @@ package openapi_test
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Instantan/web/internal/openapi"
 )
@@ -23,3 +25,245 @@ func TestValueToSchema(t *testing.T) {
 		}
 	}{})))
 }
+
+func TestValueToSchemaIsTypeDriven(t *testing.T) {
+	type Address struct {
+		City string `json:"city"`
+	}
+	type Base struct {
+		Id string `json:"id"`
+	}
+	type Node struct {
+		Children []*Node `json:"children"`
+	}
+	type User struct {
+		Base
+		Address  *Address          `json:"address,omitempty"`
+		Tags     []any             `json:"tags"`
+		Scores   map[string]int    `json:"scores"`
+		Metadata any               `json:"metadata"`
+		Created  *time.Time        `json:"created"`
+		Groups   map[string][]Base `json:"groups"`
+		Tree     Node              `json:"tree"`
+		internal string
+	}
+	schema := openapi.ValueToSchema(User{Scores: map[string]int{"a": 1}})
+
+	if schema.Properties["id"] == nil || schema.Properties["Base"] != nil {
+		t.Errorf("expected the fields of embedded structs to be promoted, got %v", schema.Properties)
+	}
+	if address := schema.Properties["address"]; address.Type != "object" || address.Properties["city"].Type != "string" {
+		t.Errorf("expected nil pointers to be described by their type, got %+v", address)
+	}
+	if tags := schema.Properties["tags"]; tags.Type != "array" || tags.Items == nil || tags.Items.Type != "" {
+		t.Errorf("expected an array of any, got %+v", tags)
+	}
+	if scores := schema.Properties["scores"]; len(scores.Properties) != 0 || scores.AdditionalProperties.Type != "integer" {
+		t.Errorf("expected maps to describe their values as additionalProperties, got %+v", scores)
+	}
+	if groups := schema.Properties["groups"]; groups.AdditionalProperties.Items.Properties["id"] == nil {
+		t.Errorf("expected nested map values to be described, got %+v", groups)
+	}
+	if metadata := schema.Properties["metadata"]; metadata.Type != "" {
+		t.Errorf("expected interfaces to accept any value, got %+v", metadata)
+	}
+	if created := schema.Properties["created"]; created.Type != "string" || created.Format != "date-time" {
+		t.Errorf("expected nil times to be date-time strings, got %+v", created)
+	}
+	if children := schema.Properties["tree"].Properties["children"]; children.Items.Type != "object" {
+		t.Errorf("expected recursive types to end, got %+v", children)
+	}
+	if _, ok := schema.Properties["internal"]; ok {
+		t.Errorf("expected unexported fields to be skipped")
+	}
+	if b, _ := json.Marshal(openapi.ValueToSchema(nil)); string(b) != "{}" {
+		t.Errorf("expected nil to accept any value, got %s", b)
+	}
+}
+
+func TestComponentSchemaReferencesNamedStructs(t *testing.T) {
+	type Item struct {
+		Name string `json:"name"`
+	}
+	type Category struct {
+		Name     string     `json:"name"`
+		Children []Category `json:"children"`
+		Items    []Item     `json:"items"`
+		Featured *Item      `json:"featured,omitempty"`
+		Inline   struct {
+			Ok bool `json:"ok"`
+		} `json:"inline"`
+	}
+	components := &openapi.Components{}
+	schema := openapi.ComponentSchema([]Category{}, components)
+	schemas := components.Schemas
+
+	if schema.Type != "array" || schema.Items.Ref != "#/components/schemas/Category" {
+		t.Fatalf("expected an array of category references, got %+v", schema)
+	}
+	if len(schemas) != 2 {
+		t.Fatalf("expected Category and Item to be registered, got %v", schemas)
+	}
+	category := schemas["Category"]
+	if children := category.Properties["children"]; children.Items.Ref != "#/components/schemas/Category" {
+		t.Errorf("expected the recursive children to reference Category, got %+v", children.Items)
+	}
+	if items := category.Properties["items"]; items.Items.Ref != "#/components/schemas/Item" {
+		t.Errorf("expected the items to reference Item, got %+v", items.Items)
+	}
+	if featured := category.Properties["featured"]; featured.Ref != "#/components/schemas/Item" {
+		t.Errorf("expected the pointer to reference Item, got %+v", featured)
+	}
+	if inline := category.Properties["inline"]; inline.Ref != "" || inline.Properties["ok"] == nil {
+		t.Errorf("expected anonymous structs to be inlined, got %+v", inline)
+	}
+	if schemas["Item"].Properties["name"].Type != "string" {
+		t.Errorf("unexpected Item schema %+v", schemas["Item"])
+	}
+
+	var value any
+	json.Unmarshal([]byte(`[{"name":"a","children":[{"name":"b","children":[],"items":[{"name":1}],"inline":{"ok":true}}],"items":[],"inline":{"ok":true}}]`), &value)
+	if violations := schema.Validate(value, schemas, "body"); len(violations) != 1 {
+		t.Errorf("expected the nested item to be validated through the references, got %v", violations)
+	}
+}
+
+type Page[T any] struct {
+	Items []T `json:"items"`
+}
+
+type Pair[K any, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+type Account struct {
+	Id string `json:"id"`
+}
+
+type legacyAccount struct {
+	Id string `json:"id"`
+}
+
+func (*legacyAccount) SchemaName() string { return "LegacyAccount" }
+
+func TestComponentSchemaNames(t *testing.T) {
+	newUser := func() any {
+		type User struct {
+			Name string `json:"name"`
+		}
+		return User{}
+	}
+	type User struct {
+		Id string `json:"id"`
+	}
+	components := &openapi.Components{Schemas: map[string]openapi.Schema{"Problem": {Type: "object"}}}
+
+	for _, c := range []struct {
+		value any
+		name  string
+	}{
+		{Page[Account]{}, "PageOfAccount"},
+		{Pair[Account, []string]{}, "PairOfAccountAndStringList"},
+		{Page[map[string]*Account]{}, "PageOfMapOfStringToAccount"},
+		{Page[Page[Account]]{}, "PageOfPageOfAccount"},
+		{legacyAccount{}, "LegacyAccount"},
+	} {
+		if schema := openapi.ComponentSchema(c.value, components); schema.Ref != "#/components/schemas/"+c.name {
+			t.Errorf("expected %T to be named %v, got %v", c.value, c.name, schema.Ref)
+		}
+	}
+	if ref := components.Schemas["PageOfAccount"].Properties["items"].Items.Ref; ref != "#/components/schemas/Account" {
+		t.Errorf("expected the type argument to be referenced, got %v", ref)
+	}
+
+	first := openapi.ComponentSchema(User{}, components)
+	second := openapi.ComponentSchema(newUser(), components)
+	if first.Ref != "#/components/schemas/User" || second.Ref != "#/components/schemas/OpenapiTestUser" {
+		t.Errorf("expected the colliding type to be qualified by its package, got %v and %v", first.Ref, second.Ref)
+	}
+	if again := openapi.ComponentSchema(newUser(), components); again.Ref != second.Ref {
+		t.Errorf("expected the type to keep its name, got %v", again.Ref)
+	}
+	if components.Schemas["User"].Properties["id"] == nil || components.Schemas["OpenapiTestUser"].Properties["name"] == nil {
+		t.Errorf("expected both users to be registered, got %v", components.Schemas)
+	}
+}
+
+type Priority int
+
+func (*Priority) Enum() []any { return []any{Priority(1), Priority(2), Priority(3)} }
+
+type Status string
+
+func (Status) Enum() []any { return []any{Status("active"), Status("inactive")} }
+
+func TestValueToSchemaEnum(t *testing.T) {
+	schema := openapi.ValueToSchema(struct {
+		Status   Status     `json:"status"`
+		Priority *Priority  `json:"priority"`
+		History  []Status   `json:"history"`
+		Labels   []Priority `json:"labels"`
+	}{})
+
+	encoded, _ := json.Marshal(schema.Properties["status"])
+	if string(encoded) != `{"type":"string","enum":["active","inactive"],"example":""}` {
+		t.Errorf("expected the values of the enum, got %s", encoded)
+	}
+	if priority := schema.Properties["priority"]; priority.Type != "integer" || len(priority.Enum) != 3 {
+		t.Errorf("expected the enum of the pointer receiver, got %+v", priority)
+	}
+	if history := schema.Properties["history"]; len(history.Items.Enum) != 2 {
+		t.Errorf("expected the items to be enums, got %+v", history.Items)
+	}
+
+	var value any
+	json.Unmarshal([]byte(`{"status":"deleted","priority":2,"history":["active"],"labels":[4]}`), &value)
+	violations := schema.Validate(value, nil, "body")
+	if len(violations) != 2 || violations[0].Location != "body.labels[0]" || violations[1].Location != "body.status" {
+		t.Errorf("expected the values outside of the enums to be rejected, got %v", violations)
+	}
+	if violations := schema.Properties["status"].ValidateString("active", nil, "query.status"); len(violations) != 0 {
+		t.Errorf("expected no violations, got %v", violations)
+	}
+}
+
+func TestSchemaAcceptsNilValues(t *testing.T) {
+	type Ref struct {
+		Id string `json:"id"`
+	}
+	type Node struct {
+		Parent   *Ref              `json:"parent"`
+		Tags     []string          `json:"tags"`
+		Labels   map[string]string `json:"labels"`
+		Next     *Ref              `json:"next,omitempty"`
+		Children []Ref             `json:"children" validate:"required"`
+	}
+	components := &openapi.Components{}
+	schema := openapi.ComponentSchema(Node{}, components)
+
+	encoded, _ := json.Marshal(Node{Children: []Ref{}})
+	var value any
+	json.Unmarshal(encoded, &value)
+	if violations := schema.Validate(value, components.Schemas, "body"); len(violations) != 0 {
+		t.Errorf("expected the encoded nil values to be valid, got %v", violations)
+	}
+	encoded, _ = json.Marshal(Node{})
+	json.Unmarshal(encoded, &value)
+	if violations := schema.Validate(value, components.Schemas, "body"); len(violations) != 1 || violations[0].Location != "body.children" {
+		t.Errorf("expected only the required slice to reject null, got %v", violations)
+	}
+
+	documented, _ := json.Marshal(components.Schemas["Node"])
+	for _, expected := range []string{
+		`"parent":{"anyOf":[{"$ref":"#/components/schemas/Ref"},{"type":"null"}]}`,
+		`"tags":{"type":["array","null"],"items":{"type":"string"}`,
+		`"labels":{"type":["object","null"],"additionalProperties":{"type":"string"}`,
+		`"next":{"$ref":"#/components/schemas/Ref"}`,
+		`"children":{"type":"array","items":{"$ref":"#/components/schemas/Ref"}`,
+	} {
+		if !strings.Contains(string(documented), expected) {
+			t.Errorf("expected %v in %s", expected, documented)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A Violation describes a single value that does not match its schema.
+type Violation struct {
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+// Validate checks a decoded JSON value against the schema. References are
+// resolved against the given component schemas.
+func (s *Schema) Validate(value any, schemas map[string]Schema, location string) []Violation {
+	if value == nil && s.Nullable {
+		return nil
+	}
+	if s.Ref != "" {
+		ref, ok := schemas[extractRefName(s.Ref)]
+		if !ok {
+			return nil
+		}
+		return ref.Validate(value, schemas, location)
+	}
+	violations := []Violation{}
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return append(violations, violation(location, "must be of type object"))
+		}
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				violations = append(violations, violation(join(location, name), "is required"))
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+			if v, ok := object[name]; ok {
+				violations = append(violations, s.Properties[name].Validate(v, schemas, join(location, name))...)
+			}
+		}
+		if s.AdditionalProperties != nil {
+			for _, name := range slices.Sorted(maps.Keys(object)) {
+				if _, ok := s.Properties[name]; !ok {
+					violations = append(violations, s.AdditionalProperties.Validate(object[name], schemas, join(location, name))...)
+				}
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return append(violations, violation(location, "must be of type array"))
+		}
+		violations = append(violations, s.validateItems(len(array), location)...)
+		if s.Items != nil {
+			for i, item := range array {
+				violations = append(violations, s.Items.Validate(item, schemas, location+"["+strconv.Itoa(i)+"]")...)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return append(violations, violation(location, "must be of type string"))
+		}
+		violations = append(violations, s.validateString(str, location)...)
+	case "integer":
+		n, ok := number(value)
+		if !ok || n != math.Trunc(n) {
+			return append(violations, violation(location, "must be of type integer"))
+		}
+		violations = append(violations, s.validateNumber(n, location)...)
+	case "number":
+		n, ok := number(value)
+		if !ok {
+			return append(violations, violation(location, "must be of type number"))
+		}
+		violations = append(violations, s.validateNumber(n, location)...)
+	case "boolean":
+		b, ok := value.(bool)
+		if !ok {
+			return append(violations, violation(location, "must be of type boolean"))
+		}
+		violations = append(violations, s.validateEnum(b, location)...)
+	case "null":
+		if value != nil {
+			violations = append(violations, violation(location, "must be null"))
+		}
+	}
+	return violations
+}
+
+// ValidateString checks a raw parameter value against the schema, parsing it
+// according to the schema type first.
+func (s *Schema) ValidateString(value string, schemas map[string]Schema, location string) []Violation {
+	if s.Ref != "" {
+		ref, ok := schemas[extractRefName(s.Ref)]
+		if !ok {
+			return nil
+		}
+		return ref.ValidateString(value, schemas, location)
+	}
+	switch s.Type {
+	case "integer":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return []Violation{violation(location, "must be of type integer")}
+		}
+		return s.validateNumber(float64(n), location)
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return []Violation{violation(location, "must be of type number")}
+		}
+		return s.validateNumber(n, location)
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return []Violation{violation(location, "must be of type boolean")}
+		}
+		return s.validateEnum(b, location)
+	case "string":
+		return s.validateString(value, location)
+	case "array":
+		if s.Items != nil {
+			return s.Items.ValidateString(value, schemas, location)
+		}
+	}
+	return nil
+}
+
+func number(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func violation(location string, message string) Violation {
+	return Violation{Location: location, Message: message}
+}
+
+func join(location string, name string) string {
+	if location == "" {
+		return name
+	}
+	return location + "." + name
+}
+
+func extractRefName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("%v %v", v.Location, v.Message)
+}
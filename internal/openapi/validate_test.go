@@ -0,0 +1,135 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Instantan/web/internal/openapi"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	type Item struct {
+		Sku string `json:"sku"`
+		Qty int    `json:"qty"`
+	}
+	schema := openapi.ValueToSchema(struct {
+		Name  string `json:"name"`
+		Items []Item `json:"items"`
+	}{})
+
+	violations := schema.Validate(map[string]any{
+		"items": []any{
+			map[string]any{"sku": "a", "qty": 1.5},
+		},
+	}, nil, "body")
+
+	expected := map[string]string{
+		"body.name":         "is required",
+		"body.items[0].qty": "must be of type integer",
+	}
+	if len(violations) != len(expected) {
+		t.Fatalf("expected %v violations, got %v", len(expected), violations)
+	}
+	for _, violation := range violations {
+		if expected[violation.Location] != violation.Message {
+			t.Errorf("unexpected violation %v", violation)
+		}
+	}
+}
+
+func TestSchemaValidateString(t *testing.T) {
+	schema := openapi.ValueToSchema(0)
+	if violations := schema.ValidateString("12", nil, "query.limit"); len(violations) != 0 {
+		t.Errorf("expected no violations, got %v", violations)
+	}
+	if violations := schema.ValidateString("twelve", nil, "query.limit"); len(violations) != 1 {
+		t.Errorf("expected one violation, got %v", violations)
+	}
+}
+
+func TestSchemaValidateAdditionalProperties(t *testing.T) {
+	schema := openapi.ValueToSchema(map[string]int{})
+	violations := schema.Validate(map[string]any{"a": 1.0, "b": "two"}, nil, "body")
+	if len(violations) != 1 || violations[0].Location != "body.b" {
+		t.Fatalf("expected body.b to be rejected, got %v", violations)
+	}
+}
+
+func TestSchemaConstraints(t *testing.T) {
+	schema := openapi.ValueToSchema(struct {
+		Name   string   `json:"name" validate:"minLength=2,maxLength=5,pattern=^[a-z]{1,}$"`
+		Age    int      `json:"age" validate:"min=18,max=130"`
+		Step   float64  `json:"step" validate:"multipleOf=0.5"`
+		Email  string   `json:"email" validate:"format=email"`
+		Status string   `json:"status" validate:"enum=draft|published"`
+		Tags   []string `json:"tags" validate:"minItems=1,maxItems=2,maxLength=3"`
+		Ids    []int    `json:"ids" validate:"enum=1|2"`
+	}{})
+
+	encoded, _ := json.Marshal(schema.Properties["age"])
+	if string(encoded) != `{"type":"integer","minimum":18,"maximum":130,"example":0}` {
+		t.Errorf("expected the constraints to be documented, got %s", encoded)
+	}
+	if tags := schema.Properties["tags"]; *tags.MinItems != 1 || tags.MaxLength != nil || *tags.Items.MaxLength != 3 {
+		t.Errorf("expected the length of the items to be constrained, got %+v", tags)
+	}
+
+	var value any
+	json.Unmarshal([]byte(`{"name":"Abcdef","age":12,"step":1.25,"email":"nope","status":"archived","tags":["a","b","long"],"ids":[1,3]}`), &value)
+	expected := map[string]string{
+		"body.name":    "must be at most 5 characters long",
+		"body.age":     "must be at least 18",
+		"body.step":    "must be a multiple of 0.5",
+		"body.email":   "must be a valid email",
+		"body.status":  "must be one of [draft published]",
+		"body.tags":    "must contain at most 2 items",
+		"body.tags[2]": "must be at most 3 characters long",
+		"body.ids[1]":  "must be one of [1 2]",
+	}
+	violations := schema.Validate(value, nil, "body")
+	if len(violations) != len(expected)+1 {
+		t.Fatalf("expected %v violations, got %v", len(expected)+1, violations)
+	}
+	for _, violation := range violations {
+		if violation.Location == "body.name" && violation.Message == "must match the pattern ^[a-z]{1,}$" {
+			continue
+		}
+		if expected[violation.Location] != violation.Message {
+			t.Errorf("unexpected violation %v", violation)
+		}
+	}
+
+	json.Unmarshal([]byte(`{"name":"abc","age":18,"step":1.5,"email":"a@example.com","status":"draft","tags":["a"],"ids":[2]}`), &value)
+	if violations := schema.Validate(value, nil, "body"); len(violations) != 0 {
+		t.Errorf("expected no violations, got %v", violations)
+	}
+	if violations := schema.Properties["age"].ValidateString("200", nil, "query.age"); len(violations) != 1 {
+		t.Errorf("expected the parameter to be constrained, got %v", violations)
+	}
+}
+
+func TestSchemaConstrainRejectsInvalidTags(t *testing.T) {
+	for _, tag := range []string{"min", "min=a", "unknown=1", "pattern=(", "multipleOf=0", "enum=1|x"} {
+		schema := openapi.Schema{Type: "integer"}
+		if err := schema.Constrain(tag); err == nil {
+			t.Errorf("expected %q to be rejected", tag)
+		}
+	}
+}
+
+func TestSchemaConstrainedIgnoresImpliedFormats(t *testing.T) {
+	implied := openapi.ValueToSchema(struct {
+		At   time.Time `json:"at"`
+		Data []byte    `json:"data"`
+	}{})
+	if implied.Constrained() {
+		t.Error("expected formats implied by the type not to be constraints")
+	}
+	tagged := openapi.ValueToSchema(struct {
+		At string `json:"at" validate:"format=date-time"`
+	}{})
+	if !tagged.Constrained() {
+		t.Error("expected the format of the validate tag to be a constraint")
+	}
+}
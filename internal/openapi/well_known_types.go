@@ -1,24 +1,30 @@
 package openapi
 
 import (
+	"encoding/json"
+	"reflect"
 	"time"
 )
 
-func schemaForWellKnownTypes(value any) *Schema {
-	switch v := value.(type) {
-	case time.Time:
-		return timeSchema(v)
-	case *time.Time:
-		return timeSchema(*v)
-	}
-	return nil
-}
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
 
-func timeSchema(t time.Time) *Schema {
-	iso := t.Format(time.RFC3339)
-	return &Schema{
-		Type:     "string",
-		Example:  iso,
-		TypeName: "Time",
+func schemaForWellKnownTypes(t reflect.Type, example any) *Schema {
+	switch t {
+	case timeType:
+		s := &Schema{
+			Type:     "string",
+			Format:   "date-time",
+			TypeName: "Time",
+		}
+		if v, ok := example.(time.Time); ok {
+			s.Example = v.Format(time.RFC3339)
+		}
+		return s
+	case rawMessageType:
+		return &Schema{}
 	}
+	return nil
 }
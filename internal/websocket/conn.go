@@ -0,0 +1,265 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Opcodes of the frames
+const (
+	OpContinuation = 0x0
+	OpText         = 0x1
+	OpBinary       = 0x2
+	OpClose        = 0x8
+	OpPing         = 0x9
+	OpPong         = 0xA
+)
+
+// Status codes sent with close frames
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// DefaultMaxMessageSize is the limit of messages read from the client
+const DefaultMaxMessageSize = 1 << 20
+
+// ErrClosed is returned when writing to a connection after the close frame
+// was sent
+var ErrClosed = errors.New("websocket: connection closed")
+
+// CloseError is returned by ReadMessage once the connection is closed, either
+// by the client or because it violated the protocol
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket: closed with %v", e.Code)
+	}
+	return fmt.Sprintf("websocket: closed with %v: %v", e.Code, e.Reason)
+}
+
+// Conn is the server side of a websocket connection. ReadMessage must not be
+// called concurrently, writes are safe for concurrent use.
+type Conn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	// MaxMessageSize is the limit of a message, larger messages close the
+	// connection with CloseMessageTooBig
+	MaxMessageSize int64
+
+	writeMu   sync.Mutex
+	closeSent bool
+}
+
+type frame struct {
+	fin     bool
+	opcode  int
+	payload []byte
+}
+
+func newConn(conn net.Conn, br *bufio.Reader) *Conn {
+	return &Conn{
+		conn:           conn,
+		br:             br,
+		MaxMessageSize: DefaultMaxMessageSize,
+	}
+}
+
+// ReadMessage reads the next text or binary message, fragmented messages are
+// reassembled. Pings are answered while reading, a close frame of the client
+// is echoed and returned as *CloseError.
+func (c *Conn) ReadMessage() (opcode int, payload []byte, err error) {
+	opcode = -1
+	for {
+		f, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch f.opcode {
+		case OpPing:
+			if err := c.writeFrame(OpPong, f.payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case OpPong:
+			continue
+		case OpClose:
+			return 0, nil, c.closed(f.payload)
+		case OpText, OpBinary:
+			if opcode != -1 {
+				return 0, nil, c.fail(CloseProtocolError, "expected a continuation frame")
+			}
+			opcode, payload = f.opcode, f.payload
+		case OpContinuation:
+			if opcode == -1 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+			payload = append(payload, f.payload...)
+		default:
+			return 0, nil, c.fail(CloseProtocolError, fmt.Sprintf("unknown opcode %v", f.opcode))
+		}
+		if c.MaxMessageSize > 0 && int64(len(payload)) > c.MaxMessageSize {
+			return 0, nil, c.fail(CloseMessageTooBig, "")
+		}
+		if f.fin {
+			if opcode == OpText && !utf8.Valid(payload) {
+				return 0, nil, c.fail(CloseInvalidPayload, "text message is not valid utf-8")
+			}
+			return opcode, payload, nil
+		}
+	}
+}
+
+// WriteMessage sends the payload as a single unfragmented frame
+func (c *Conn) WriteMessage(opcode int, payload []byte) error {
+	if opcode != OpText && opcode != OpBinary {
+		return fmt.Errorf("websocket: %v is no data opcode", opcode)
+	}
+	return c.writeFrame(opcode, payload)
+}
+
+// Ping sends a ping, the client answers with a pong carrying the same data
+func (c *Conn) Ping(data []byte) error {
+	return c.writeFrame(OpPing, data)
+}
+
+// WriteClose sends a close frame, no further messages can be written
+func (c *Conn) WriteClose(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+	return c.writeFrame(OpClose, payload)
+}
+
+// Close sends a close frame with the code if none was sent yet and closes
+// the underlying connection
+func (c *Conn) Close(code int, reason string) error {
+	c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	// fails with ErrClosed if a close frame was sent already
+	c.WriteClose(code, reason)
+	return c.conn.Close()
+}
+
+// SetReadDeadline sets the deadline of the next read
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// closed answers the close frame of the client
+func (c *Conn) closed(payload []byte) error {
+	err := &CloseError{Code: CloseNoStatus}
+	switch {
+	case len(payload) == 1:
+		return c.fail(CloseProtocolError, "invalid close frame")
+	case len(payload) >= 2:
+		err.Code = int(binary.BigEndian.Uint16(payload))
+		err.Reason = string(payload[2:])
+	}
+	code := err.Code
+	if code == CloseNoStatus {
+		code = CloseNormal
+	}
+	c.Close(code, "")
+	return err
+}
+
+// fail closes the connection because the client violated the protocol
+func (c *Conn) fail(code int, reason string) error {
+	c.Close(code, reason)
+	return &CloseError{Code: code, Reason: reason}
+}
+
+func (c *Conn) readFrame() (frame, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.br, header); err != nil {
+		return frame{}, err
+	}
+	f := frame{
+		fin:    header[0]&0x80 != 0,
+		opcode: int(header[0] & 0x0f),
+	}
+	if header[0]&0x70 != 0 {
+		return f, c.fail(CloseProtocolError, "reserved bits are set")
+	}
+	if header[1]&0x80 == 0 {
+		return f, c.fail(CloseProtocolError, "client frames must be masked")
+	}
+	length := uint64(header[1] & 0x7f)
+	isControl := f.opcode&0x8 != 0
+	if isControl && (!f.fin || length > 125) {
+		return f, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	switch length {
+	case 126:
+		b := make([]byte, 2)
+		if _, err := io.ReadFull(c.br, b); err != nil {
+			return f, err
+		}
+		length = uint64(binary.BigEndian.Uint16(b))
+	case 127:
+		b := make([]byte, 8)
+		if _, err := io.ReadFull(c.br, b); err != nil {
+			return f, err
+		}
+		length = binary.BigEndian.Uint64(b)
+	}
+	if c.MaxMessageSize > 0 && length > uint64(c.MaxMessageSize) {
+		return f, c.fail(CloseMessageTooBig, "")
+	}
+	mask := make([]byte, 4)
+	if _, err := io.ReadFull(c.br, mask); err != nil {
+		return f, err
+	}
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, f.payload); err != nil {
+		return f, err
+	}
+	for i := range f.payload {
+		f.payload[i] ^= mask[i%4]
+	}
+	return f, nil
+}
+
+// writeFrame writes an unmasked final frame, servers must not mask
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return ErrClosed
+	}
+	// marked while holding the lock, so no frame can follow the close frame
+	c.closeSent = opcode == OpClose
+	header := []byte{0x80 | byte(opcode)}
+	switch length := len(payload); {
+	case length <= 125:
+		header = append(header, byte(length))
+	case length <= 0xffff:
+		header = binary.BigEndian.AppendUint16(append(header, 126), uint16(length))
+	default:
+		header = binary.BigEndian.AppendUint64(append(header, 127), uint64(length))
+	}
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Package websocket implements the server side of the WebSocket protocol
+// (RFC 6455) on top of net/http.
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// guid is appended to the key of the client to compute the accept header
+const guid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// HandshakeError is returned by Upgrade for requests which are no valid
+// opening handshake, nothing has been written to the response yet
+type HandshakeError struct {
+	Status  int
+	Message string
+}
+
+func (e *HandshakeError) Error() string {
+	return "websocket: " + e.Message
+}
+
+func (e *HandshakeError) StatusCode() int {
+	return e.Status
+}
+
+// Accept computes the Sec-WebSocket-Accept header for the
+// Sec-WebSocket-Key of the client
+func Accept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + guid))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// IsUpgrade reports if the request asks to switch to the websocket protocol
+func IsUpgrade(r *http.Request) bool {
+	return hasToken(r.Header, "Connection", "upgrade") && hasToken(r.Header, "Upgrade", "websocket")
+}
+
+// SameOrigin reports if the Origin header of the request is missing, as for
+// clients other than browsers, or has the host of the request
+func SameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// Upgrade completes the opening handshake and takes over the connection.
+// The headers already set on w are sent with the 101 response, the response
+// writer must not be used afterwards. Browsers send the cookies of the user
+// with handshakes from any site, so handshakes are rejected unless
+// checkOrigin reports true, it defaults to SameOrigin.
+func Upgrade(w http.ResponseWriter, r *http.Request, checkOrigin func(r *http.Request) bool) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		return nil, &HandshakeError{Status: http.StatusMethodNotAllowed, Message: "the opening handshake must be a GET request"}
+	}
+	if !IsUpgrade(r) {
+		w.Header().Set("Upgrade", "websocket")
+		return nil, &HandshakeError{Status: http.StatusUpgradeRequired, Message: "the request does not ask to upgrade to websocket"}
+	}
+	if checkOrigin == nil {
+		checkOrigin = SameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, &HandshakeError{Status: http.StatusForbidden, Message: "the origin " + r.Header.Get("Origin") + " is not allowed"}
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, &HandshakeError{Status: http.StatusUpgradeRequired, Message: "unsupported Sec-WebSocket-Version"}
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, &HandshakeError{Status: http.StatusBadRequest, Message: "invalid Sec-WebSocket-Key"}
+	}
+
+	header := w.Header().Clone()
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("websocket: %w", err)
+	}
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	header.Set("Upgrade", "websocket")
+	header.Set("Connection", "Upgrade")
+	header.Set("Sec-WebSocket-Accept", Accept(key))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	header.Write(rw)
+	rw.WriteString("\r\n")
+	if err := rw.Flush(); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("websocket: %w", err)
+	}
+	return newConn(netConn, rw.Reader), nil
+}
+
+// hasToken reports if the comma separated header contains the token
+func hasToken(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,266 @@
+package websocket_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Instantan/web/internal/websocket"
+)
+
+func TestAccept(t *testing.T) {
+	// example of RFC 6455 section 1.3
+	if accept := websocket.Accept("dGhlIHNhbXBsZSBub25jZQ=="); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept %v", accept)
+	}
+}
+
+func TestUpgradeRejectsInvalidHandshakes(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		status int
+	}{
+		{"no upgrade", map[string]string{}, http.StatusUpgradeRequired},
+		{"wrong version", map[string]string{"Connection": "keep-alive, Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "8"}, http.StatusUpgradeRequired},
+		{"invalid key", map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "short"}, http.StatusBadRequest},
+		{"cross origin", map[string]string{"Connection": "Upgrade", "Upgrade": "websocket", "Sec-WebSocket-Version": "13", "Sec-WebSocket-Key": "dGhlIHNhbXBsZSBub25jZQ==", "Origin": "https://evil.example"}, http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for key, value := range test.header {
+				r.Header.Set(key, value)
+			}
+			_, err := websocket.Upgrade(httptest.NewRecorder(), r, nil)
+			var handshakeErr *websocket.HandshakeError
+			if !errors.As(err, &handshakeErr) || handshakeErr.Status != test.status {
+				t.Fatalf("expected status %v, got %v", test.status, err)
+			}
+		})
+	}
+}
+
+func TestEcho(t *testing.T) {
+	client := dial(t, func(conn *websocket.Conn) {
+		for {
+			opcode, payload, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(opcode, payload)
+		}
+	})
+
+	client.write(t, true, websocket.OpText, []byte("hello"))
+	if opcode, payload := client.read(t); opcode != websocket.OpText || string(payload) != "hello" {
+		t.Fatalf("unexpected echo %v %q", opcode, payload)
+	}
+
+	// a ping between the fragments is answered before the message completes
+	client.write(t, false, websocket.OpBinary, []byte("frag"))
+	client.write(t, true, websocket.OpPing, []byte("p"))
+	client.write(t, true, websocket.OpContinuation, []byte("ments"))
+	if opcode, payload := client.read(t); opcode != websocket.OpPong || string(payload) != "p" {
+		t.Fatalf("expected pong, got %v %q", opcode, payload)
+	}
+	if opcode, payload := client.read(t); opcode != websocket.OpBinary || string(payload) != "fragments" {
+		t.Fatalf("unexpected echo %v %q", opcode, payload)
+	}
+
+	large := []byte(strings.Repeat("x", 70000))
+	client.write(t, true, websocket.OpBinary, large)
+	if _, payload := client.read(t); len(payload) != len(large) {
+		t.Fatalf("expected %v bytes, got %v", len(large), len(payload))
+	}
+
+	client.write(t, true, websocket.OpClose, binary.BigEndian.AppendUint16(nil, websocket.CloseGoingAway))
+	if opcode, payload := client.read(t); opcode != websocket.OpClose || binary.BigEndian.Uint16(payload) != websocket.CloseGoingAway {
+		t.Fatalf("expected close echo, got %v %v", opcode, payload)
+	}
+}
+
+func TestCloseOnProtocolViolations(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(c *client)
+		code  int
+	}{
+		{"unmasked frame", func(c *client) { c.writeRaw(t, []byte{0x81, 0x01, 'a'}) }, websocket.CloseProtocolError},
+		{"unexpected continuation", func(c *client) { c.write(t, true, websocket.OpContinuation, []byte("a")) }, websocket.CloseProtocolError},
+		{"invalid utf-8", func(c *client) { c.write(t, true, websocket.OpText, []byte{0xff}) }, websocket.CloseInvalidPayload},
+		{"message too big", func(c *client) { c.write(t, true, websocket.OpBinary, make([]byte, 11)) }, websocket.CloseMessageTooBig},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs := make(chan error, 1)
+			c := dial(t, func(conn *websocket.Conn) {
+				conn.MaxMessageSize = 10
+				_, _, err := conn.ReadMessage()
+				errs <- err
+			})
+			test.write(c)
+			opcode, payload := c.read(t)
+			if opcode != websocket.OpClose || int(binary.BigEndian.Uint16(payload)) != test.code {
+				t.Fatalf("expected close with %v, got %v %v", test.code, opcode, payload)
+			}
+			var closeErr *websocket.CloseError
+			if err := <-errs; !errors.As(err, &closeErr) || closeErr.Code != test.code {
+				t.Fatalf("expected close error %v, got %v", test.code, err)
+			}
+		})
+	}
+}
+
+func TestNoFramesAfterClose(t *testing.T) {
+	c := dial(t, func(conn *websocket.Conn) {
+		wg := sync.WaitGroup{}
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for conn.WriteMessage(websocket.OpText, []byte("data")) == nil {
+				}
+			}()
+		}
+		for range 2 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				conn.WriteClose(websocket.CloseGoingAway, "")
+			}()
+		}
+		wg.Wait()
+	})
+	for {
+		opcode, _ := c.read(t)
+		if opcode == websocket.OpClose {
+			break
+		}
+		if opcode != websocket.OpText {
+			t.Fatalf("unexpected opcode %v", opcode)
+		}
+	}
+	if rest, _ := io.ReadAll(c.br); len(rest) > 0 {
+		t.Fatalf("expected no frames after the close frame, got %v bytes", len(rest))
+	}
+}
+
+type client struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dial(t *testing.T, handler func(conn *websocket.Conn)) *client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "kept")
+		conn, err := websocket.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close(websocket.CloseNormal, "")
+		handler(conn)
+	}))
+	t.Cleanup(server.Close)
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Key: "+key+"\r\n\r\n")
+	br := bufio.NewReader(conn)
+	response, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols || response.Header.Get("Sec-WebSocket-Accept") != websocket.Accept(key) {
+		t.Fatalf("unexpected handshake response %v %v", response.Status, response.Header)
+	}
+	if response.Header.Get("X-Test") != "kept" {
+		t.Fatalf("expected the headers of the response writer, got %v", response.Header)
+	}
+	return &client{conn: conn, br: br}
+}
+
+func (c *client) writeRaw(t *testing.T, b []byte) {
+	t.Helper()
+	if _, err := c.conn.Write(b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// write sends a masked frame like a browser does
+func (c *client) write(t *testing.T, fin bool, opcode int, payload []byte) {
+	t.Helper()
+	first := byte(opcode)
+	if fin {
+		first |= 0x80
+	}
+	b := []byte{first}
+	switch {
+	case len(payload) <= 125:
+		b = append(b, 0x80|byte(len(payload)))
+	case len(payload) <= 0xffff:
+		b = binary.BigEndian.AppendUint16(append(b, 0x80|126), uint16(len(payload)))
+	default:
+		b = binary.BigEndian.AppendUint64(append(b, 0x80|127), uint64(len(payload)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	b = append(b, mask...)
+	for i, v := range payload {
+		b = append(b, v^mask[i%4])
+	}
+	c.writeRaw(t, b)
+}
+
+func (c *client) read(t *testing.T) (int, []byte) {
+	t.Helper()
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.br, header); err != nil {
+		t.Fatal(err)
+	}
+	if header[1]&0x80 != 0 {
+		t.Fatal("server frames must not be masked")
+	}
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		b := make([]byte, 2)
+		io.ReadFull(c.br, b)
+		length = uint64(binary.BigEndian.Uint16(b))
+	case 127:
+		b := make([]byte, 8)
+		io.ReadFull(c.br, b)
+		length = binary.BigEndian.Uint64(b)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		t.Fatal(err)
+	}
+	return int(header[0] & 0x0f), payload
+}
+
+func TestSameOrigin(t *testing.T) {
+	for origin, same := range map[string]bool{"": true, "https://example.com": true, "http://EXAMPLE.com": true, "https://evil.example": false, "https://example.com.evil.example": false, "null": false} {
+		r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if websocket.SameOrigin(r) != same {
+			t.Errorf("expected origin %q to be the same: %v", origin, same)
+		}
+	}
+}
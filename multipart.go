@@ -0,0 +1,186 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Instantan/web/internal/openapi"
+)
+
+// File is a file uploaded with a multipart/form-data body. Bodies with File
+// fields are documented and decoded as multipart/form-data, their fields are
+// named by their form tag.
+type File struct {
+	Filename    string
+	ContentType string
+	Size        int64
+	header      *multipart.FileHeader
+}
+
+// Open opens the uploaded file, larger files are streamed to a temporary file
+// which is removed after the handler returned
+func (f File) Open() (multipart.File, error) {
+	if f.header == nil {
+		return nil, fmt.Errorf("file %q has no content", f.Filename)
+	}
+	return f.header.Open()
+}
+
+// Multipart limits the size of multipart/form-data bodies
+type Multipart struct {
+	// MaxMemory is the number of bytes kept in memory, the rest of the files
+	// is written to temporary files
+	MaxMemory int64
+	// MaxSize is the maximum size of the whole body
+	MaxSize int64
+	// MaxFileSize is the maximum size of a single file, it defaults to MaxSize
+	MaxFileSize int64
+}
+
+var defaultMultipart = Multipart{
+	MaxMemory: 8 << 20,
+	MaxSize:   32 << 20,
+}
+
+var (
+	fileType      = reflect.TypeFor[File]()
+	fileSliceType = reflect.TypeFor[[]File]()
+)
+
+// hasFiles reports if t is a struct with File fields
+func hasFiles(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	t = indirect(t)
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		switch indirect(t.Field(i).Type) {
+		case fileType, fileSliceType:
+			return true
+		}
+	}
+	return false
+}
+
+// decodeMultipart reads the form into the struct v points to, files larger
+// than MaxMemory are streamed to disk. The form is stored in
+// r.MultipartForm and has to be removed by the caller.
+func decodeMultipart(r *http.Request, v any, limits Multipart) error {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || params["boundary"] == "" {
+		return &BindError{In: "body", Err: fmt.Errorf("missing multipart boundary")}
+	}
+	body := r.Body
+	if limits.MaxSize > 0 {
+		body = http.MaxBytesReader(nil, body, limits.MaxSize)
+	}
+	form, err := multipart.NewReader(body, params["boundary"]).ReadForm(limits.MaxMemory)
+	if err != nil {
+		if maxBytesError := (*http.MaxBytesError)(nil); errors.As(err, &maxBytesError) {
+			return NewProblem(http.StatusRequestEntityTooLarge, fmt.Sprintf("body exceeds %v bytes", limits.MaxSize))
+		}
+		return &BindError{In: "body", Err: err}
+	}
+	r.MultipartForm = form
+
+	maxFileSize := limits.MaxFileSize
+	if maxFileSize == 0 {
+		maxFileSize = limits.MaxSize
+	}
+	value := reflect.ValueOf(v).Elem()
+	t := value.Type()
+	for i, name := range fieldNames(t, "form") {
+		if name == "" {
+			continue
+		}
+		field := value.Field(i)
+		_, options, _ := strings.Cut(t.Field(i).Tag.Get("form"), ",")
+		required := field.Kind() != reflect.Pointer && field.Kind() != reflect.Slice && options != "optional"
+		switch indirect(field.Type()) {
+		case fileType, fileSliceType:
+			headers := form.File[name]
+			if len(headers) == 0 {
+				if required {
+					return &BindError{In: "body", Name: name, Err: fmt.Errorf("is required")}
+				}
+				continue
+			}
+			files := []File{}
+			for _, header := range headers {
+				if maxFileSize > 0 && header.Size > maxFileSize {
+					return NewProblem(http.StatusRequestEntityTooLarge, fmt.Sprintf("file %q exceeds %v bytes", header.Filename, maxFileSize))
+				}
+				files = append(files, File{
+					Filename:    header.Filename,
+					ContentType: header.Header.Get("Content-Type"),
+					Size:        header.Size,
+					header:      header,
+				})
+			}
+			if indirect(field.Type()) == fileSliceType {
+				reflect.Indirect(allocate(field)).Set(reflect.ValueOf(files))
+			} else {
+				reflect.Indirect(allocate(field)).Set(reflect.ValueOf(files[0]))
+			}
+		default:
+			values := form.Value[name]
+			if len(values) == 0 {
+				if required {
+					return &BindError{In: "body", Name: name, Err: fmt.Errorf("is required")}
+				}
+				continue
+			}
+			if err := parseValues(field, values); err != nil {
+				return &BindError{In: "body", Name: name, Err: err}
+			}
+		}
+	}
+	return nil
+}
+
+// multipartMediaType documents the struct t as multipart/form-data, files are
+// binary strings whose content type is taken from the contentType tag
+func multipartMediaType(t reflect.Type) openapi.MediaType {
+	t = indirect(t)
+	schema := openapi.Schema{
+		Type:       "object",
+		Properties: map[string]*openapi.Schema{},
+	}
+	encoding := map[string]openapi.Encoding{}
+	for i, name := range fieldNames(t, "form") {
+		if name == "" {
+			continue
+		}
+		field := t.Field(i)
+		_, options, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if field.Type.Kind() != reflect.Pointer && field.Type.Kind() != reflect.Slice && options != "optional" {
+			schema.Required = append(schema.Required, name)
+		}
+		switch indirect(field.Type) {
+		case fileType:
+			schema.Properties[name] = &openapi.Schema{Type: "string", Format: "binary"}
+		case fileSliceType:
+			schema.Properties[name] = &openapi.Schema{Type: "array", Items: &openapi.Schema{Type: "string", Format: "binary"}}
+		default:
+			schema.Properties[name] = openapi.ValueToSchema(exampleOf(field.Type))
+			continue
+		}
+		contentType := field.Tag.Get("contentType")
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		encoding[name] = openapi.Encoding{ContentType: contentType}
+	}
+	return openapi.MediaType{
+		Schema:   schema,
+		Encoding: encoding,
+	}
+}
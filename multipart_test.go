@@ -0,0 +1,73 @@
+package web_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+type upload struct {
+	Title  string   `form:"title"`
+	Avatar web.File `form:"avatar" contentType:"image/png"`
+}
+
+func TestMultipartUploads(t *testing.T) {
+	w := newWeb()
+	w.Multipart(web.Multipart{MaxSize: 1 << 10})
+	w.Api(web.Api{
+		Method: http.MethodPost,
+		Path:   "/avatars",
+		Handler: web.Handle(func(r *http.Request, in struct{ Body upload }) (string, error) {
+			f, err := in.Body.Avatar.Open()
+			if err != nil {
+				return "", err
+			}
+			defer f.Close()
+			content, err := io.ReadAll(f)
+			return fmt.Sprintf("%v %v %v %s", in.Body.Title, in.Body.Avatar.Filename, in.Body.Avatar.ContentType, content), err
+		}),
+	})
+
+	schema := lookup(spec(t, w), "paths", "/avatars", "post", "requestBody", "content", "multipart/form-data", "schema")
+	if lookup(schema, "properties", "avatar", "format") != "binary" {
+		t.Errorf("expected the file to be documented as binary, got %v", schema)
+	}
+
+	body, contentType := multipartBody(t, "me", []byte("png"))
+	r := httptest.NewRequest(http.MethodPost, "/avatars", body)
+	r.Header.Set("Content-Type", contentType)
+	if resp, body := serve(t, w, r); resp.StatusCode != http.StatusOK || body != `"me avatar.png image/png png"`+"\n" {
+		t.Errorf("expected the upload to be bound, got %v %q", resp.StatusCode, body)
+	}
+
+	body, contentType = multipartBody(t, "me", bytes.Repeat([]byte("x"), 2<<10))
+	r = httptest.NewRequest(http.MethodPost, "/avatars", body)
+	r.Header.Set("Content-Type", contentType)
+	if resp, body := serve(t, w, r); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected a body above MaxSize to be rejected, got %v %v", resp.StatusCode, body)
+	}
+}
+
+func multipartBody(t *testing.T, title string, avatar []byte) (io.Reader, string) {
+	t.Helper()
+	b := &bytes.Buffer{}
+	form := multipart.NewWriter(b)
+	form.WriteField("title", title)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="avatar"; filename="avatar.png"`)
+	header.Set("Content-Type", "image/png")
+	part, err := form.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(avatar)
+	form.Close()
+	return b, form.FormDataContentType()
+}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type negotiatedKey struct{}
+
+// NegotiatedContentType returns the media type chosen from the declared
+// ContentType variants of the route by the Accept header of the request. It
+// is empty if the route does not declare variants.
+func NegotiatedContentType(r *http.Request) string {
+	contentType, _ := r.Context().Value(negotiatedKey{}).(string)
+	return contentType
+}
+
+// negotiation picks the best of the offered media types for the Accept header
+// and stores it in the request context. Requests which accept none of them
+// are answered with 406.
+func negotiation(offers []string) Use {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept")
+			contentType, ok := negotiate(r.Header.Get("Accept"), offers)
+			if !ok {
+				WriteProblem(w, r, NewProblem(http.StatusNotAcceptable, "none of the supported media types is acceptable").With("supported", offers))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), negotiatedKey{}, contentType)))
+		})
+	}
+}
+
+// negotiableContentTypes returns the media types of all ContentType variants
+// declared for successful responses
+func negotiableContentTypes(responses Responses) []string {
+	offers := []string{}
+	for status, value := range responses.Iterate() {
+		if status >= 300 {
+			continue
+		}
+		if c := isContentType(value); c != nil {
+			for contentType := range c.Iterate() {
+				if !slices.Contains(offers, contentType) {
+					offers = append(offers, contentType)
+				}
+			}
+		}
+	}
+	return offers
+}
+
+type mediaRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+func parseAccept(header string) []mediaRange {
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+	return ranges
+}
+
+// specificity returns how specific the range matches the media type or -1 if
+// it does not match
+func (m mediaRange) specificity(mediaType string) int {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	switch {
+	case m.typ == "*" && m.subtype == "*":
+		return 0
+	case m.typ == typ && m.subtype == "*":
+		return 1
+	case m.typ == typ && m.subtype == subtype:
+		return 2
+	}
+	return -1
+}
+
+// negotiate returns the offer with the highest quality, ties are won by the
+// earlier offer. Without an Accept header the first offer is chosen.
+func negotiate(accept string, offers []string) (string, bool) {
+	if len(offers) == 0 {
+		return "", false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if s := r.specificity(offer); s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, best != ""
+}
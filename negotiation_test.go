@@ -0,0 +1,100 @@
+package web_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+func TestNegotiation(t *testing.T) {
+	w := newWeb()
+	w.Codec("application/xml", web.XmlCodec{})
+	w.Api(web.Api{
+		Method:    http.MethodGet,
+		Path:      "/items",
+		Responses: web.Responses{StatusOK: item{}},
+		Handler:   web.Handle(func(r *http.Request, in struct{}) (item, error) { return item{Name: "a"}, nil }),
+	})
+
+	if _, ok := responses(t, spec(t, w), "/items", http.MethodGet)["406"]; !ok {
+		t.Error("expected the 406 to be documented")
+	}
+	cases := []struct {
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"application/xml", http.StatusOK, "application/xml"},
+		{"application/json;q=0.5, application/xml", http.StatusOK, "application/xml"},
+		{"*/*", http.StatusOK, "application/json"},
+		{"text/plain", http.StatusNotAcceptable, "application/problem+json"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/items", nil)
+		r.Header.Set("Accept", c.accept)
+		resp, body := serve(t, w, r)
+		if resp.StatusCode != c.status || !strings.HasPrefix(resp.Header.Get("Content-Type"), c.contentType) {
+			t.Errorf("Accept %v: expected %v %v, got %v %v %v", c.accept, c.status, c.contentType, resp.StatusCode, resp.Header.Get("Content-Type"), body)
+		}
+	}
+}
+
+func TestContentTypeVariantsAreNegotiated(t *testing.T) {
+	w := newWeb()
+	w.Api(web.Api{
+		Method: http.MethodGet,
+		Path:   "/items",
+		Responses: web.Responses{
+			StatusOK: web.ContentType{ApplicationJson: item{}, ApplicationXml: item{}},
+		},
+		Handler: web.Handle(func(r *http.Request, in struct{}) (item, error) { return item{Name: "a"}, nil }),
+	})
+
+	cases := []struct {
+		accept      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"application/xml", http.StatusOK, "application/xml", "<item><Name>a</Name></item>"},
+		{"application/xml;q=0.5, application/json", http.StatusOK, "application/json", `{"name":"a"}`},
+		{"", http.StatusOK, "application/json", `{"name":"a"}`},
+		{"text/csv", http.StatusNotAcceptable, "application/problem+json", "application/xml"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/items", nil)
+		if c.accept != "" {
+			r.Header.Set("Accept", c.accept)
+		}
+		resp, body := serve(t, w, r)
+		if resp.StatusCode != c.status || !strings.HasPrefix(resp.Header.Get("Content-Type"), c.contentType) || !strings.Contains(body, c.body) {
+			t.Errorf("Accept %q: expected %v %v %v, got %v %v %v", c.accept, c.status, c.contentType, c.body, resp.StatusCode, resp.Header.Get("Content-Type"), body)
+		}
+		if resp.Header.Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: expected to vary by Accept, got %q", c.accept, resp.Header.Get("Vary"))
+		}
+	}
+}
+
+func TestSocketsAreNotNegotiated(t *testing.T) {
+	w := newWeb()
+	w.Codec("application/xml", web.XmlCodec{})
+	w.Socket(web.Socket{
+		Path: "/events",
+		Handler: web.Messages(func(r *http.Request, in struct{}, conn *web.Conn[item, item]) error {
+			return nil
+		}),
+	})
+
+	if _, ok := responses(t, spec(t, w), "/events", http.MethodGet)["406"]; ok {
+		t.Error("expected no 406 to be documented for a websocket")
+	}
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	r.Header.Set("Accept", "text/plain")
+	if resp, body := serve(t, w, r); resp.StatusCode != http.StatusUpgradeRequired {
+		t.Errorf("expected 426 for a request without upgrade, got %v %v", resp.StatusCode, body)
+	}
+}
@@ -34,6 +34,7 @@ func (or *openapiUiSwagger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			SwaggerUIStandalonePreset
 			],
 			layout: "StandaloneLayout",
+			persistAuthorization: true,
 		});
 		};
 	</script>
@@ -0,0 +1,206 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Instantan/web/internal/openapi"
+)
+
+// Problem is a RFC 9457 problem details object. It implements error, so
+// handlers created with Handle can return it directly.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Status: status,
+		Detail: detail,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.title()
+}
+
+func (p *Problem) StatusCode() int {
+	if p.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return p.Status
+}
+
+func (p *Problem) With(key string, value any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = map[string]any{}
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+func (p *Problem) title() string {
+	if p.Title != "" {
+		return p.Title
+	}
+	return http.StatusText(p.StatusCode())
+}
+
+func (p Problem) MarshalJSON() ([]byte, error) {
+	m := map[string]any{}
+	for key, value := range p.Extensions {
+		m[key] = value
+	}
+	m["type"] = "about:blank"
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+	m["title"] = p.title()
+	m["status"] = p.StatusCode()
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	m := map[string]any{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*p = Problem{}
+	for key, value := range m {
+		switch key {
+		case "type":
+			p.Type, _ = value.(string)
+		case "title":
+			p.Title, _ = value.(string)
+		case "status":
+			status, _ := value.(float64)
+			p.Status = int(status)
+		case "detail":
+			p.Detail, _ = value.(string)
+		case "instance":
+			p.Instance, _ = value.(string)
+		default:
+			p.With(key, value)
+		}
+	}
+	return nil
+}
+
+// WriteProblem writes the problem as application/problem+json
+func WriteProblem(w http.ResponseWriter, r *http.Request, problem *Problem) {
+	p := *problem
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.StatusCode())
+	json.NewEncoder(w).Encode(p)
+}
+
+// ProblemOf converts any error into a problem. Errors which provide a
+// StatusCode keep their status, all others become a 500. The message of
+// server errors is never exposed.
+func ProblemOf(err error) *Problem {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem
+	}
+	status := http.StatusInternalServerError
+	var statusErr interface{ StatusCode() int }
+	if errors.As(err, &statusErr) {
+		status = statusErr.StatusCode()
+	}
+	if status >= http.StatusInternalServerError {
+		return NewProblem(status, "")
+	}
+	return NewProblem(status, err.Error())
+}
+
+func isProblem(value any) bool {
+	switch value.(type) {
+	case Problem, *Problem:
+		return true
+	}
+	return false
+}
+
+func problemSchema() openapi.Schema {
+	return openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"type":     {Type: "string", Example: "about:blank"},
+			"title":    {Type: "string", Example: "Not Found"},
+			"status":   {Type: "integer", Example: http.StatusNotFound},
+			"detail":   {Type: "string"},
+			"instance": {Type: "string"},
+		},
+		Required: []string{"type", "title", "status"},
+		TypeName: "Problem",
+	}
+}
+
+func problemResponse(status int) openapi.Response {
+	return openapi.Response{
+		Description: http.StatusText(status),
+		Content: map[string]openapi.MediaType{
+			"application/problem+json": {
+				Schema: openapi.Schema{Ref: "#/components/schemas/Problem"},
+			},
+		},
+	}
+}
+
+// problemFallback answers requests the mux has no route for with a 404 or
+// 405 problem instead of the plain text responses of http.ServeMux
+func problemFallback(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		rec := &statusRecorder{header: http.Header{}, status: http.StatusNotFound}
+		h.ServeHTTP(rec, r)
+		if allow := rec.header.Get("Allow"); allow != "" {
+			w.Header().Set("Allow", allow)
+		}
+		WriteProblem(w, r, NewProblem(rec.status, ""))
+	})
+}
+
+type statusRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusRecorder) Header() http.Header {
+	return w.header
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+}
+
+func (w *statusRecorder) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	return len(p), nil
+}
@@ -0,0 +1,104 @@
+package web_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+type invalidInput struct {
+	Field string `json:"field"`
+}
+
+func TestResponsesIterateYieldsEveryStatusOnce(t *testing.T) {
+	responses := web.Responses{}
+	for status := 100; status < 600; status++ {
+		responses.Set(status, status)
+	}
+	seen := map[int]int{}
+	for status, value := range responses.Iterate() {
+		if value != status && status != 0 {
+			t.Errorf("status %v yields the value of %v", status, value)
+		}
+		seen[status]++
+	}
+	for status := 100; status < 600; status++ {
+		if http.StatusText(status) == "" {
+			continue
+		}
+		if seen[status] != 1 {
+			t.Errorf("expected %v to be yielded once, got %v", status, seen[status])
+		}
+	}
+}
+
+func TestDeclaredBadRequestKeepsItsSchema(t *testing.T) {
+	w := newWeb()
+	w.Validation(web.Validation{Responses: true, Strict: true})
+	w.Api(web.Api{
+		Method:    http.MethodPost,
+		Path:      "/items",
+		Parameter: web.Parameter{Query: web.Query{"id": web.QueryParam{Value: ""}}},
+		Responses: web.Responses{StatusOK: item{}, StatusBadRequest: invalidInput{}},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"field":"id"}`))
+		}),
+	})
+
+	badRequest := lookup(responses(t, spec(t, w), "/items", http.MethodPost), "400", "content", "application/json", "schema", "$ref")
+	if badRequest != "#/components/schemas/InvalidInput" {
+		t.Errorf("expected the declared 400 to be documented, got %v", badRequest)
+	}
+	resp, body := serve(t, w, httptest.NewRequest(http.MethodPost, "/items?id=1", nil))
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(body, `"field":"id"`) {
+		t.Errorf("expected the declared 400 to pass the response validation, got %v %v", resp.StatusCode, body)
+	}
+}
+
+func TestProblemRendering(t *testing.T) {
+	w := newWeb()
+	w.Api(web.Api{
+		Method: http.MethodGet,
+		Path:   "/posts/{id}",
+		Handler: web.Handle(func(r *http.Request, in struct {
+			Id string `path:"id"`
+		}) (item, error) {
+			if in.Id == "crash" {
+				return item{}, errSecret
+			}
+			return item{}, web.NewProblem(http.StatusNotFound, "post does not exist").With("id", in.Id)
+		}),
+	})
+
+	resp, body := serve(t, w, httptest.NewRequest(http.MethodGet, "/posts/1", nil))
+	if resp.StatusCode != http.StatusNotFound || resp.Header.Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("expected a 404 problem, got %v %v", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	for _, expected := range []string{`"detail":"post does not exist"`, `"id":"1"`, `"instance":"/posts/1"`, `"title":"Not Found"`, `"type":"about:blank"`} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %v in %v", expected, body)
+		}
+	}
+
+	resp, body = serve(t, w, httptest.NewRequest(http.MethodGet, "/posts/crash", nil))
+	if resp.StatusCode != http.StatusInternalServerError || strings.Contains(body, errSecret.Error()) {
+		t.Errorf("expected a 500 hiding the error, got %v %v", resp.StatusCode, body)
+	}
+
+	resp, _ = serve(t, w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	if resp.StatusCode != http.StatusNotFound || resp.Header.Get("Content-Type") != "application/problem+json" {
+		t.Errorf("expected unknown routes to be answered with a problem, got %v", resp.StatusCode)
+	}
+	resp, _ = serve(t, w, httptest.NewRequest(http.MethodDelete, "/posts/1", nil))
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Content-Type") != "application/problem+json" {
+		t.Errorf("expected wrong methods to be answered with a problem, got %v", resp.StatusCode)
+	}
+}
+
+var errSecret = errors.New("connection to db-internal:5432 refused")
@@ -4,6 +4,9 @@ import (
 	"net/http"
 )
 
+// Parameter describes the inputs of an Api. The Validate field of path, query,
+// header and cookie parameters takes the constraints of the validate tag of
+// bound structs like min=1,max=100.
 type Parameter struct {
 	Query  Query
 	Path   Path
@@ -17,22 +20,27 @@ type Path map[string]PathParam
 type PathParam struct {
 	Description string
 	Value       any
+	Validate    string
 }
 
 type Query map[string]QueryParam
 
 type QueryParam struct {
 	Optional    bool
+	Deprecated  bool
 	Description string
 	Value       any
+	Validate    string
 }
 
 type Header map[string]HeaderField
 
 type HeaderField struct {
 	Optional    bool
+	Deprecated  bool
 	Description string
 	Value       any
+	Validate    string
 }
 
 type Cookie map[string]CookieField
@@ -41,6 +49,7 @@ type CookieField struct {
 	Optional    bool
 	Description string
 	Value       any
+	Validate    string
 }
 
 type Body struct {
@@ -143,6 +152,11 @@ func (r *Responses) Iterate() func(func(status int, value any) bool) {
 				return
 			}
 		}
+		if r.StatusBadRequest != nil {
+			if !yield(http.StatusBadRequest, r.StatusBadRequest) {
+				return
+			}
+		}
 		if r.StatusConflict != nil {
 			if !yield(http.StatusConflict, r.StatusConflict) {
 				return
@@ -373,11 +387,6 @@ func (r *Responses) Iterate() func(func(status int, value any) bool) {
 				return
 			}
 		}
-		if r.StatusServiceUnavailable != nil {
-			if !yield(http.StatusServiceUnavailable, r.StatusServiceUnavailable) {
-				return
-			}
-		}
 		if r.StatusSwitchingProtocols != nil {
 			if !yield(http.StatusSwitchingProtocols, r.StatusSwitchingProtocols) {
 				return
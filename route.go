@@ -1,9 +1,13 @@
 package web
 
 import (
+	"fmt"
 	"net/http"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Instantan/web/internal/openapi"
 )
@@ -18,11 +22,33 @@ type Api struct {
 	Description string
 	Parameter   Parameter
 	Responses   Responses
-	Handler     http.Handler
+	Security    []SecurityRequirement
+	Servers     []Server
+	Deprecated  bool
+	// DeprecatedAt is the date the Api was or will be deprecated, it is sent
+	// as the Deprecation header and implies Deprecated
+	DeprecatedAt time.Time
+	// Sunset is the date after which the Api will not be available anymore,
+	// setting it implies Deprecated
+	Sunset time.Time
+	// Replacement hints at the operation to use instead, either an
+	// OperationId or an url
+	Replacement string
+	// Versions the Api is carried over to in addition to the version of its group
+	Versions []string
+	// DeprecatedIn lists the versions in which the Api is documented as deprecated
+	DeprecatedIn []string
+	// Callbacks are requests sent to urls the client passes with the
+	// request, keyed by their names
+	Callbacks map[string]Callback
+	Handler   http.Handler
+	// socket holds the messages of routes added with Socket
+	socket *socketMessages
 }
 
 type Group struct {
 	routes *[]route
+	prefix string
 }
 
 type Static struct {
@@ -35,11 +61,15 @@ type Static struct {
 }
 
 type route struct {
-	use    *Use
-	api    *Api
-	static *Static
-	tag    *Tag
-	group  *Group
+	use        *Use
+	api        *Api
+	static     *Static
+	tag        *Tag
+	group      *Group
+	validation *Validation
+	security   *[]SecurityRequirement
+	servers    *[]Server
+	version    *string
 }
 
 type tags struct {
@@ -47,6 +77,20 @@ type tags struct {
 	references []string
 }
 
+// scope holds the state a group inherits from its parents
+type scope struct {
+	use        Use
+	tags       *tags
+	validation Validation
+	security   []SecurityRequirement
+	schemes    map[string]SecurityScheme
+	servers    []Server
+	version    string
+	versions   []Version
+	versioned  *[]versionedOperation
+	codecs     *codecs
+}
+
 func (g Group) Use(use Use) {
 	*g.routes = append(*g.routes, route{
 		use: &use,
@@ -72,6 +116,12 @@ func (g Group) Api(api Api) {
 	})
 	assertIsNotEmpty("Api.Path", api.Path)
 	assertIsNotNil("Api.Handler", api.Handler)
+	for _, server := range api.Servers {
+		assertIsValidServer(server)
+	}
+	for name, callback := range api.Callbacks {
+		assertIsValidCallback(name, callback)
+	}
 	*g.routes = append(*g.routes, route{
 		api: &api,
 	})
@@ -86,6 +136,7 @@ func (g Group) Static(static Static) {
 func (g Group) Group(group func(Group)) {
 	gr := Group{
 		routes: &[]route{},
+		prefix: g.prefix,
 	}
 	group(gr)
 	*g.routes = append(*g.routes, route{
@@ -93,6 +144,29 @@ func (g Group) Group(group func(Group)) {
 	})
 }
 
+// Mount creates a group whose routes are served below the prefix. The
+// prefix is prepended to the Path of every Api and the PathPrefix of every
+// Static, nested groups compose their prefixes.
+func (g Group) Mount(prefix string, group func(Group)) {
+	assertIsNotEmpty("Mount.prefix", prefix)
+	if !strings.HasPrefix(prefix, "/") {
+		panic(fmt.Errorf("Mount.prefix %v must start with /", prefix))
+	}
+	gr := Group{
+		routes: &[]route{},
+		prefix: g.prefix + strings.TrimSuffix(prefix, "/"),
+	}
+	group(gr)
+	*g.routes = append(*g.routes, route{
+		group: &gr,
+	})
+}
+
+func (s scope) clone() scope {
+	s.tags = s.tags.clone()
+	return s
+}
+
 func (t *tags) clone() *tags {
 	return &tags{
 		tags:       t.tags,
@@ -116,40 +190,83 @@ func (t *tags) openapiTags() []openapi.Tag {
 	return tags
 }
 
-func (g Group) openapiPaths(mux *http.ServeMux, components *openapi.Components, use Use, tags *tags) *openapi.Paths {
+func (g Group) openapiPaths(mux *http.ServeMux, components *openapi.Components, scope scope) *openapi.Paths {
 	paths := &openapi.Paths{}
 
 	for i := range *g.routes {
 		r := (*g.routes)[i]
 		if r.api != nil {
-			api := r.api
+			api := *r.api
+			api.Path = g.prefix + api.Path
+			th, typed := api.Handler.(typedHandler)
+			if typed {
+				th.describe(&api)
+			}
+			// typed handlers speak every registered codec supporting their types
+			mediaTypesOf := func(value any) []string {
+				if typed {
+					return scope.codecs.supporting(reflect.TypeOf(value))
+				}
+				return []string{"application/json"}
+			}
+			// variantsOf yields the declared ContentType variants of the value
+			variantsOf := func(value any) func(func(string, any) bool) {
+				if c := isContentType(value); c != nil {
+					return c.Iterate()
+				}
+				return func(yield func(string, any) bool) {
+					for _, mediaType := range mediaTypesOf(value) {
+						if !yield(mediaType, value) {
+							return
+						}
+					}
+				}
+			}
+			mediaTypeOf := func(contentType string, value any) openapi.MediaType {
+				if contentType == "multipart/form-data" && indirect(reflect.TypeOf(value)).Kind() == reflect.Struct {
+					return multipartMediaType(reflect.TypeOf(value))
+				}
+				if scope.codecs.isBinary(contentType, value) {
+					return openapi.MediaType{Schema: openapi.Schema{Type: "string", Format: "binary"}}
+				}
+				return openapi.MediaType{Example: value, Schema: componentSchema(components, value)}
+			}
 
 			p, _ := paths.Get(api.Path)
+			if len(scope.servers) > 0 {
+				p.Servers = openapiServers(scope.servers)
+			}
+
+			security := scope.security
+			if api.Security != nil {
+				security = api.Security
+			}
+			assertIsDeclaredSecurity(security, components.SecuritySchemes)
 
 			operation := &openapi.Operation{
 				OperationId: api.OperationId,
-				Tags:        tags.references,
+				Tags:        scope.tags.references,
 				Summary:     api.Summary,
-				Description: api.Description,
+				Description: api.deprecationDescription(),
+				Deprecated:  api.isDeprecated(),
 				Responses: openapi.Responses{
 					HTTPStatusCodeResponses: map[string]openapi.Response{},
 				},
 				Parameters: []openapi.Parameter{},
+				Security:   openapiSecurity(security),
+				Servers:    openapiServers(api.Servers),
+				Callbacks:  openapiCallbacks(api.Callbacks, components),
 			}
 
 			if api.Parameter.Body.Value != nil {
+				operation.RequestBody = &openapi.RequestBody{
+					Content: map[string]openapi.MediaType{},
+				}
 				operation.RequestBody.Required = !api.Parameter.Body.Optional
 				operation.RequestBody.Description = api.Parameter.Body.Description
-				content := operation.RequestBody.Content["text/*"]
-				content.Example = api.Parameter.Body.Value
-				s := *openapi.ValueToSchema(api.Parameter.Body.Value)
-				if s.TypeName == "" {
-					content.Schema = s
-				} else {
-					content.Schema.Ref = "#/components/schemas/" + s.TypeName
-					components.Schemas[s.TypeName] = s
+				for contentType, value := range variantsOf(api.Parameter.Body.Value) {
+					operation.RequestBody.Content[contentType] = mediaTypeOf(contentType, value)
 				}
-				operation.RequestBody.Content["application/json"] = content
 			}
 			if len(api.Parameter.Cookie) > 0 {
 				for key, value := range api.Parameter.Cookie {
@@ -158,7 +275,7 @@ func (g Group) openapiPaths(mux *http.ServeMux, components *openapi.Components,
 						In:          "cookie",
 						Description: value.Description,
 						Required:    !value.Optional,
-						Schema:      *openapi.ValueToSchema(value.Value),
+						Schema:      parameterSchema(key, value.Value, value.Validate),
 						Example:     value.Value,
 					})
 				}
@@ -170,7 +287,7 @@ func (g Group) openapiPaths(mux *http.ServeMux, components *openapi.Components,
 						In:          "path",
 						Description: value.Description,
 						Required:    true,
-						Schema:      *openapi.ValueToSchema(value.Value),
+						Schema:      parameterSchema(key, value.Value, value.Validate),
 						Example:     value.Value,
 					})
 				}
@@ -182,7 +299,8 @@ func (g Group) openapiPaths(mux *http.ServeMux, components *openapi.Components,
 						In:          "query",
 						Description: value.Description,
 						Required:    !value.Optional,
-						Schema:      *openapi.ValueToSchema(value.Value),
+						Deprecated:  value.Deprecated,
+						Schema:      parameterSchema(key, value.Value, value.Validate),
 						Example:     value.Value,
 					})
 				}
@@ -194,32 +312,22 @@ func (g Group) openapiPaths(mux *http.ServeMux, components *openapi.Components,
 						In:          "header",
 						Description: value.Description,
 						Required:    !value.Optional,
-						Schema:      *openapi.ValueToSchema(value.Value),
+						Deprecated:  value.Deprecated,
+						Schema:      parameterSchema(key, value.Value, value.Validate),
 						Example:     value.Value,
 					})
 				}
 			}
 
 			createResponse := func(description string, value any) openapi.Response {
-				c := isContentType(value)
-				if c == nil {
-					c = &ContentType{
-						ApplicationJson: value,
-					}
+				if isProblem(value) {
+					response := problemResponse(http.StatusInternalServerError)
+					response.Description = description
+					return response
 				}
-
 				mediaTypes := map[string]openapi.MediaType{}
-				for contentType, value := range c.Iterate() {
-					content := openapi.MediaType{}
-					s := *openapi.ValueToSchema(value)
-					content.Example = value
-					if s.TypeName == "" {
-						content.Schema = s
-					} else {
-						content.Schema.Ref = "#/components/schemas/" + s.TypeName
-						components.Schemas[s.TypeName] = s
-					}
-					mediaTypes[contentType] = content
+				for contentType, value := range variantsOf(value) {
+					mediaTypes[contentType] = mediaTypeOf(contentType, value)
 				}
 
 				return openapi.Response{
@@ -236,59 +344,201 @@ func (g Group) openapiPaths(mux *http.ServeMux, components *openapi.Components,
 				operation.Responses.HTTPStatusCodeResponses[strconv.Itoa(status)] = createResponse(http.StatusText(status), value)
 			}
 
-			switch api.Method {
-			case http.MethodGet:
-				p.Get = operation
-			case http.MethodPut:
-				p.Put = operation
-			case http.MethodPost:
-				p.Post = operation
-			case http.MethodDelete:
-				p.Delete = operation
-			case http.MethodHead:
-				p.Head = operation
-			case http.MethodPatch:
-				p.Patch = operation
-			case http.MethodTrace:
-				p.Trace = operation
-			case http.MethodConnect:
-				p.Trace = operation
-			case http.MethodOptions:
-				p.Options = operation
+			if api.isSocket() {
+				operation.Responses.HTTPStatusCodeResponses[strconv.Itoa(http.StatusSwitchingProtocols)] = openapi.Response{
+					Description: http.StatusText(http.StatusSwitchingProtocols),
+				}
+				operation.WebSocket = &openapi.WebSocket{}
+				if api.socket.receive != nil {
+					receive := componentSchema(components, api.socket.receive)
+					operation.WebSocket.Receive = &receive
+				}
+				if api.socket.send != nil {
+					send := componentSchema(components, api.socket.send)
+					operation.WebSocket.Send = &send
+				}
 			}
 
+			problems := []int{}
+			if typed {
+				problems = append(problems, http.StatusBadRequest)
+			}
+			if api.isSocket() {
+				problems = append(problems, http.StatusUpgradeRequired, http.StatusForbidden)
+			}
+			consumes := []string{}
+			if c := isContentType(api.Parameter.Body.Value); c != nil {
+				for contentType := range c.Iterate() {
+					consumes = append(consumes, contentType)
+				}
+			}
+			if len(consumes) > 0 || typed && api.Parameter.Body.Value != nil {
+				problems = append(problems, http.StatusUnsupportedMediaType)
+			}
+			if typed && api.Parameter.Body.Value != nil && openapi.ValueToSchema(api.Parameter.Body.Value).Constrained() {
+				// the body is checked against its validate tags while binding
+				problems = append(problems, http.StatusUnprocessableEntity)
+			}
+			if typed && (hasFiles(reflect.TypeOf(api.Parameter.Body.Value)) || slices.Contains(consumes, "multipart/form-data")) {
+				problems = append(problems, http.StatusRequestEntityTooLarge)
+			}
+			if scope.validation.Requests {
+				if len(operation.Parameters) > 0 || operation.RequestBody != nil {
+					problems = append(problems, http.StatusBadRequest)
+				}
+				if operation.RequestBody != nil || slices.ContainsFunc(operation.Parameters, func(p openapi.Parameter) bool { return p.Schema.Constrained() }) {
+					problems = append(problems, http.StatusUnprocessableEntity)
+				}
+				if operation.RequestBody != nil {
+					problems = append(problems, http.StatusRequestEntityTooLarge)
+				}
+			}
+			if isEnforced(security, scope.schemes) {
+				problems = append(problems, http.StatusUnauthorized, http.StatusForbidden)
+			}
+			offers := negotiableContentTypes(api.Responses)
+			if supported := mediaTypesOf(api.Responses.StatusOK); len(offers) == 0 && typed && len(supported) > 1 {
+				offers = supported
+			}
+			if api.isSocket() {
+				// the upgrade has no response body to negotiate
+				offers = nil
+			}
+			if len(offers) > 0 {
+				problems = append(problems, http.StatusNotAcceptable)
+			}
+			for _, status := range problems {
+				if _, ok := operation.Responses.HTTPStatusCodeResponses[strconv.Itoa(status)]; !ok {
+					operation.Responses.HTTPStatusCodeResponses[strconv.Itoa(status)] = problemResponse(status)
+				}
+			}
+
+			setOperation(&p, api.Method, operation)
 			paths.Set(api.Path, p)
-			if use == nil {
-				mux.Handle(api.Method+" "+api.Path, api.Handler)
-			} else {
-				mux.Handle(api.Method+" "+api.Path, use(api.Handler))
+			*scope.versioned = append(*scope.versioned, versionedOperation{
+				path:         api.Path,
+				method:       api.Method,
+				operation:    operation,
+				servers:      p.Servers,
+				version:      scope.version,
+				versions:     api.Versions,
+				deprecatedIn: api.DeprecatedIn,
+			})
+
+			handler := api.Handler
+			if typed {
+				handler = withCodecs(scope.codecs)(handler)
+			}
+			if scope.validation.Responses && !api.isStreamed() && !api.isSocket() {
+				handler = validateResponses(operation, components, scope.validation)(handler)
 			}
+			if scope.validation.Requests {
+				handler = validateRequests(operation, components, scope.validation)(handler)
+			}
+			if len(consumes) > 0 {
+				handler = consumesContentTypes(consumes)(handler)
+			}
+			if len(offers) > 0 {
+				handler = negotiation(offers)(handler)
+			}
+			if isEnforced(security, scope.schemes) {
+				handler = authenticate(security, scope.schemes)(handler)
+			}
+			if api := api.withVersionDeprecation(scope.versions, scope.version); api.isDeprecated() {
+				handler = deprecation(api)(handler)
+			}
+			if scope.use != nil {
+				handler = scope.use(handler)
+			}
+			mux.Handle(api.Method+" "+api.Path, handler)
 		} else if r.group != nil {
 			group := r.group
-			for path, item := range group.openapiPaths(mux, components, use, tags.clone()).Iterate() {
-				paths.Set(path, item)
+			for path, item := range group.openapiPaths(mux, components, scope.clone()).Iterate() {
+				p, _ := paths.Get(path)
+				for method, operation := range item.IterateOperations() {
+					setOperation(&p, method, operation)
+				}
+				if len(item.Servers) > 0 {
+					p.Servers = item.Servers
+				}
+				paths.Set(path, p)
 			}
 		} else if r.tag != nil {
-			tags.add(*r.tag)
+			scope.tags.add(*r.tag)
+		} else if r.validation != nil {
+			scope.validation = *r.validation
+		} else if r.security != nil {
+			scope.security = *r.security
+		} else if r.servers != nil {
+			scope.servers = *r.servers
+		} else if r.version != nil {
+			scope.version = *r.version
 		} else if r.use != nil {
-			if use != nil {
-				use = Chain(use, *r.use)
+			if scope.use != nil {
+				scope.use = Chain(scope.use, *r.use)
 			} else {
-				use = *r.use
+				scope.use = *r.use
 			}
 		} else if r.static != nil {
+			pathPrefix := g.prefix + r.static.PathPrefix
 			var handler http.Handler
-			if use != nil {
-				handler = http.StripPrefix(r.static.PathPrefix, use(http.FileServer(r.static.FS)))
+			if scope.use != nil {
+				handler = http.StripPrefix(pathPrefix, scope.use(http.FileServer(r.static.FS)))
 			} else {
-				handler = http.StripPrefix(r.static.PathPrefix, http.FileServer(r.static.FS))
+				handler = http.StripPrefix(pathPrefix, http.FileServer(r.static.FS))
 			}
 			if r.static.SpaMode {
-				mux.Handle(http.MethodGet+" "+r.static.PathPrefix, createSpaModeRedirect(handler))
+				mux.Handle(http.MethodGet+" "+pathPrefix, createSpaModeRedirect(handler, pathPrefix))
 			} else {
-				mux.Handle(http.MethodGet+" "+r.static.PathPrefix, handler)
+				mux.Handle(http.MethodGet+" "+pathPrefix, handler)
 			}
 		}
 	}
 	return paths
 }
+
+// parameterSchema returns the schema of the parameter with the constraints of
+// its Validate
+func parameterSchema(name string, value any, validate string) openapi.Schema {
+	schema := openapi.ValueToSchema(value)
+	if err := schema.Constrain(validate); err != nil {
+		panic(fmt.Errorf("parameter %v: %w", name, err))
+	}
+	return *schema
+}
+
+// SchemaNamer is implemented by types naming their schema in the components
+// of the spec, e.g. to keep the name of a renamed type
+type SchemaNamer = openapi.SchemaNamer
+
+// Enumer is implemented by types with a fixed set of values. The values are
+// documented as enum, typed as union in TypeScript and others are rejected
+// while binding.
+type Enumer = openapi.Enumer
+
+// componentSchema returns the schema of the value, named structs are added
+// to the components and referenced
+func componentSchema(components *openapi.Components, value any) openapi.Schema {
+	return openapi.ComponentSchema(value, components)
+}
+
+func setOperation(p *openapi.PathItem, method string, operation *openapi.Operation) {
+	switch method {
+	case http.MethodGet:
+		p.Get = operation
+	case http.MethodPut:
+		p.Put = operation
+	case http.MethodPost:
+		p.Post = operation
+	case http.MethodDelete:
+		p.Delete = operation
+	case http.MethodHead:
+		p.Head = operation
+	case http.MethodPatch:
+		p.Patch = operation
+	case http.MethodTrace:
+		p.Trace = operation
+	case http.MethodOptions:
+		p.Options = operation
+	}
+}
@@ -0,0 +1,34 @@
+package web_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+func TestMountPrefixesRoutes(t *testing.T) {
+	w := newWeb()
+	w.Mount("/v1", func(g web.Group) {
+		g.Mount("/users", func(g web.Group) {
+			g.Api(web.Api{
+				Method: http.MethodGet,
+				Path:   "/{id}",
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(r.PathValue("id")))
+				}),
+			})
+		})
+	})
+
+	if resp, body := serve(t, w, httptest.NewRequest(http.MethodGet, "/v1/users/42", nil)); resp.StatusCode != http.StatusOK || body != "42" {
+		t.Errorf("expected the mounted route to be served, got %v %v", resp.StatusCode, body)
+	}
+	if resp, _ := serve(t, w, httptest.NewRequest(http.MethodGet, "/users/42", nil)); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected the route without prefix to be missing, got %v", resp.StatusCode)
+	}
+	if lookup(spec(t, w), "paths", "/v1/users/{id}", "get") == nil {
+		t.Error("expected the prefixed path to be documented")
+	}
+}
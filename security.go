@@ -0,0 +1,142 @@
+package web
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Instantan/web/internal/openapi"
+)
+
+type SecurityScheme struct {
+	// Name under which the scheme is referenced by a SecurityRequirement
+	Name        string
+	Type        string
+	Description string
+
+	// In and ParameterName define where an apiKey is read from
+	In            string
+	ParameterName string
+
+	// Scheme is the http authorization scheme like basic or bearer, only
+	// these two can be enforced by a Verifier
+	Scheme       string
+	BearerFormat string
+
+	Flows *OAuthFlows
+
+	OpenIdConnectUrl string
+
+	// Verifier enforces the scheme on every route which requires it
+	Verifier Verifier
+}
+
+type OAuthFlows struct {
+	Implicit          *OAuthFlow
+	Password          *OAuthFlow
+	ClientCredentials *OAuthFlow
+	AuthorizationCode *OAuthFlow
+}
+
+type OAuthFlow struct {
+	AuthorizationUrl string
+	TokenUrl         string
+	RefreshUrl       string
+	Scopes           map[string]string
+}
+
+// SecurityRequirement maps the names of security schemes to the required
+// scopes. All schemes of a requirement have to be satisfied.
+type SecurityRequirement map[string][]string
+
+func (g Group) Security(security ...SecurityRequirement) {
+	*g.routes = append(*g.routes, route{
+		security: &security,
+	})
+}
+
+func assertIsValidSecurityScheme(scheme SecurityScheme) {
+	assertIsNotEmpty("SecurityScheme.Name", scheme.Name)
+	assertIsOneOf(scheme.Type, []string{"apiKey", "http", "oauth2", "openIdConnect"})
+	switch scheme.Type {
+	case "apiKey":
+		assertIsOneOf(scheme.In, []string{"query", "header", "cookie"})
+		assertIsNotEmpty("SecurityScheme.ParameterName", scheme.ParameterName)
+	case "http":
+		assertIsNotEmpty("SecurityScheme.Scheme", scheme.Scheme)
+		if scheme.Verifier != nil {
+			// only the credentials of these schemes can be read from a request
+			assertIsOneOf(strings.ToLower(scheme.Scheme), []string{"basic", "bearer"})
+		}
+	case "oauth2":
+		if scheme.Flows == nil {
+			panic(fmt.Errorf("SecurityScheme.Flows must not be NIL"))
+		}
+	case "openIdConnect":
+		assertIsNotEmpty("SecurityScheme.OpenIdConnectUrl", scheme.OpenIdConnectUrl)
+	}
+}
+
+func assertIsDeclaredSecurity(security []SecurityRequirement, schemes map[string]openapi.SecurityScheme) {
+	for _, requirement := range security {
+		for name := range requirement {
+			if _, ok := schemes[name]; !ok {
+				panic(fmt.Errorf("security scheme %v is not declared", name))
+			}
+		}
+	}
+}
+
+func (scheme SecurityScheme) openapiSecurityScheme() openapi.SecurityScheme {
+	s := openapi.SecurityScheme{
+		Type:             scheme.Type,
+		Description:      scheme.Description,
+		Name:             scheme.ParameterName,
+		In:               scheme.In,
+		Scheme:           scheme.Scheme,
+		BearerFormat:     scheme.BearerFormat,
+		OpenIdConnectUrl: scheme.OpenIdConnectUrl,
+	}
+	if scheme.Flows != nil {
+		s.Flows = &openapi.OAuthFlows{
+			Implicit:          scheme.Flows.Implicit.openapiOAuthFlow(),
+			Password:          scheme.Flows.Password.openapiOAuthFlow(),
+			ClientCredentials: scheme.Flows.ClientCredentials.openapiOAuthFlow(),
+			AuthorizationCode: scheme.Flows.AuthorizationCode.openapiOAuthFlow(),
+		}
+	}
+	return s
+}
+
+func (flow *OAuthFlow) openapiOAuthFlow() *openapi.OAuthFlow {
+	if flow == nil {
+		return nil
+	}
+	scopes := flow.Scopes
+	if scopes == nil {
+		scopes = map[string]string{}
+	}
+	return &openapi.OAuthFlow{
+		AuthorizationUrl: flow.AuthorizationUrl,
+		TokenUrl:         flow.TokenUrl,
+		RefreshUrl:       flow.RefreshUrl,
+		Scopes:           scopes,
+	}
+}
+
+func openapiSecurity(security []SecurityRequirement) []openapi.SecurityRequirement {
+	if security == nil {
+		return nil
+	}
+	requirements := make([]openapi.SecurityRequirement, len(security))
+	for i, requirement := range security {
+		r := openapi.SecurityRequirement{}
+		for name, scopes := range requirement {
+			if scopes == nil {
+				scopes = []string{}
+			}
+			r[name] = scopes
+		}
+		requirements[i] = r
+	}
+	return requirements
+}
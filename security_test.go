@@ -0,0 +1,51 @@
+package web_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+func TestSecurityIsDocumented(t *testing.T) {
+	w := newWeb()
+	w.SecurityScheme(web.SecurityScheme{Name: "bearerAuth", Type: "http", Scheme: "bearer", BearerFormat: "JWT"})
+	w.SecurityScheme(web.SecurityScheme{Name: "apiKey", Type: "apiKey", In: "header", ParameterName: "X-Api-Key"})
+	w.Security(web.SecurityRequirement{"bearerAuth": nil})
+	w.Api(web.Api{
+		Method:  http.MethodGet,
+		Path:    "/me",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	})
+	w.Api(web.Api{
+		Method:   http.MethodGet,
+		Path:     "/stats",
+		Security: []web.SecurityRequirement{{"apiKey": nil}},
+		Handler:  http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	})
+	doc := spec(t, w)
+
+	if lookup(doc, "components", "securitySchemes", "bearerAuth", "scheme") != "bearer" || lookup(doc, "components", "securitySchemes", "apiKey", "name") != "X-Api-Key" {
+		t.Errorf("expected the schemes to be documented, got %v", lookup(doc, "components", "securitySchemes"))
+	}
+	if security, _ := lookup(doc, "paths", "/me", "get", "security").([]any); len(security) != 1 || lookup(security[0], "bearerAuth") == nil {
+		t.Errorf("expected /me to inherit the requirement of the Web, got %v", security)
+	}
+	if security, _ := lookup(doc, "paths", "/stats", "get", "security").([]any); len(security) != 1 || lookup(security[0], "apiKey") == nil {
+		t.Errorf("expected /stats to override the requirement, got %v", security)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected an undeclared scheme to panic")
+		}
+	}()
+	w = newWeb()
+	w.Api(web.Api{
+		Method:   http.MethodGet,
+		Path:     "/me",
+		Security: []web.SecurityRequirement{{"unknown": nil}},
+		Handler:  http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	})
+	w.Server()
+}
@@ -0,0 +1,77 @@
+package web
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/Instantan/web/internal/openapi"
+)
+
+type Server struct {
+	// Url of the server, variables are written in {brackets}
+	Url         string
+	Description string
+	Variables   map[string]ServerVariable
+}
+
+type ServerVariable struct {
+	Default     string
+	Enum        []string
+	Description string
+}
+
+var serverVariablePattern = regexp.MustCompile(`{([^}]+)}`)
+
+func (g Group) Servers(servers ...Server) {
+	for _, server := range servers {
+		assertIsValidServer(server)
+	}
+	*g.routes = append(*g.routes, route{
+		servers: &servers,
+	})
+}
+
+func assertIsValidServer(server Server) {
+	assertIsNotEmpty("Server.Url", server.Url)
+	for _, match := range serverVariablePattern.FindAllStringSubmatch(server.Url, -1) {
+		if _, ok := server.Variables[match[1]]; !ok {
+			panic(fmt.Errorf("Server.Variables must contain %v used in %v", match[1], server.Url))
+		}
+	}
+	for name, variable := range server.Variables {
+		assertIsNotEmpty("ServerVariable.Default of "+name, variable.Default)
+		if len(variable.Enum) > 0 && !slices.Contains(variable.Enum, variable.Default) {
+			panic(fmt.Errorf("ServerVariable.Default of %v must be one of %v", name, variable.Enum))
+		}
+	}
+}
+
+func (server Server) openapiServer() openapi.Server {
+	s := openapi.Server{
+		Url:         server.Url,
+		Description: server.Description,
+	}
+	if len(server.Variables) > 0 {
+		s.Variables = map[string]openapi.ServerVariable{}
+		for name, variable := range server.Variables {
+			s.Variables[name] = openapi.ServerVariable{
+				Enum:        variable.Enum,
+				Default:     variable.Default,
+				Description: variable.Description,
+			}
+		}
+	}
+	return s
+}
+
+func openapiServers(servers []Server) []openapi.Server {
+	if len(servers) == 0 {
+		return nil
+	}
+	s := make([]openapi.Server, len(servers))
+	for i, server := range servers {
+		s[i] = server.openapiServer()
+	}
+	return s
+}
@@ -0,0 +1,43 @@
+package web_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+func TestServersAreDocumentedPerScope(t *testing.T) {
+	w := newWeb()
+	w.Servers(web.Server{
+		Url: "https://{environment}.example.com",
+		Variables: map[string]web.ServerVariable{
+			"environment": {Default: "production", Enum: []string{"production", "staging"}},
+		},
+	})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	w.Group(func(g web.Group) {
+		g.Servers(web.Server{Url: "https://files.example.com"})
+		g.Api(web.Api{Method: http.MethodGet, Path: "/files", Handler: handler})
+	})
+	w.Api(web.Api{
+		Method:  http.MethodGet,
+		Path:    "/users",
+		Servers: []web.Server{{Url: "https://users.example.com"}},
+		Handler: handler,
+	})
+	doc := spec(t, w)
+
+	if lookup(doc, "servers").([]any)[0].(map[string]any)["url"] != "https://{environment}.example.com" {
+		t.Errorf("expected the servers of the Web, got %v", lookup(doc, "servers"))
+	}
+	if lookup(doc, "servers").([]any)[0].(map[string]any)["variables"] == nil {
+		t.Errorf("expected the variables to be documented, got %v", lookup(doc, "servers"))
+	}
+	if servers, _ := lookup(doc, "paths", "/files", "servers").([]any); len(servers) != 1 || servers[0].(map[string]any)["url"] != "https://files.example.com" {
+		t.Errorf("expected the servers of the group on its paths, got %v", servers)
+	}
+	if servers, _ := lookup(doc, "paths", "/users", "get", "servers").([]any); len(servers) != 1 || servers[0].(map[string]any)["url"] != "https://users.example.com" {
+		t.Errorf("expected the servers of the Api on its operation, got %v", servers)
+	}
+}
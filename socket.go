@@ -0,0 +1,233 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/Instantan/web/internal/websocket"
+)
+
+// Socket is a route upgrading to a websocket which exchanges JSON messages
+type Socket struct {
+	Path        string
+	OperationId string
+	Summary     string
+	Description string
+	Parameter   Parameter
+	Security    []SecurityRequirement
+	Deprecated  bool
+	// Receive and Send are examples of the messages received from and sent to
+	// the client, handlers created with Messages derive them from their type
+	Receive any
+	Send    any
+	// Heartbeat is the interval of the pings keeping idle connections alive,
+	// it defaults to 30 seconds. A negative value disables them.
+	Heartbeat time.Duration
+	// CheckOrigin reports if a handshake with the Origin header of the request
+	// is accepted. Browsers send the cookies of the user with handshakes from
+	// any site, by default only handshakes of the same host or without Origin
+	// are accepted.
+	CheckOrigin func(r *http.Request) bool
+	Handler     http.Handler
+}
+
+// CloseError is returned by Conn.Receive once the connection is closed
+type CloseError = websocket.CloseError
+
+// Status codes to close a Conn with
+const (
+	CloseNormal          = websocket.CloseNormal
+	CloseGoingAway       = websocket.CloseGoingAway
+	ClosePolicyViolation = websocket.ClosePolicyViolation
+	CloseInternalError   = websocket.CloseInternalError
+)
+
+// Conn is a websocket connection receiving messages of type Receive from the
+// client and sending messages of type Send to it
+type Conn[Receive, Send any] struct {
+	conn   *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+const defaultSocketHeartbeat = 30 * time.Second
+
+type socketMessages struct {
+	receive any
+	send    any
+}
+
+type messagesHandler[In, Receive, Send any] struct {
+	handler     func(r *http.Request, in In, conn *Conn[Receive, Send]) error
+	binder      *binder
+	heartbeat   time.Duration
+	checkOrigin func(r *http.Request) bool
+}
+
+// originChecker is implemented by handlers created with Messages
+type originChecker interface {
+	withCheckOrigin(checkOrigin func(r *http.Request) bool) http.Handler
+}
+
+// Messages creates a http.Handler which binds the request to In like Handle
+// and upgrades it to a websocket. The connection is closed once the handler
+// returns, errors close it with CloseInternalError or with
+// ClosePolicyViolation if they are a Problem below 500.
+func Messages[In, Receive, Send any](h func(r *http.Request, in In, conn *Conn[Receive, Send]) error) http.Handler {
+	return &messagesHandler[In, Receive, Send]{
+		handler:   h,
+		binder:    newBinder(reflect.TypeFor[In]()),
+		heartbeat: defaultSocketHeartbeat,
+	}
+}
+
+func (g Group) Socket(socket Socket) {
+	assertIsNotEmpty("Socket.Path", socket.Path)
+	assertIsNotNil("Socket.Handler", socket.Handler)
+	handler := socket.Handler
+	if h, ok := handler.(heartbeater); ok && socket.Heartbeat != 0 {
+		handler = h.withHeartbeat(socket.Heartbeat)
+	}
+	if h, ok := handler.(originChecker); ok && socket.CheckOrigin != nil {
+		handler = h.withCheckOrigin(socket.CheckOrigin)
+	}
+	g.Api(Api{
+		Method:      http.MethodGet,
+		Path:        socket.Path,
+		OperationId: socket.OperationId,
+		Summary:     socket.Summary,
+		Description: socket.Description,
+		Parameter:   socket.Parameter,
+		Security:    socket.Security,
+		Deprecated:  socket.Deprecated,
+		Handler:     handler,
+		socket: &socketMessages{
+			receive: socket.Receive,
+			send:    socket.Send,
+		},
+	})
+}
+
+func (h *messagesHandler[In, Receive, Send]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var in In
+	if err := h.binder.bind(r, reflect.ValueOf(&in).Elem()); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	ws, err := websocket.Upgrade(w, r, h.checkOrigin)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	conn := &Conn[Receive, Send]{conn: ws, ctx: ctx, cancel: cancel}
+	if h.heartbeat > 0 {
+		go conn.keepAlive(h.heartbeat)
+	}
+
+	err = h.handler(r.WithContext(ctx), in, conn)
+	var closeErr *CloseError
+	switch {
+	case err == nil || errors.As(err, &closeErr):
+		conn.Close(CloseNormal, "")
+	case ProblemOf(err).Status < http.StatusInternalServerError:
+		conn.Close(ClosePolicyViolation, ProblemOf(err).Detail)
+	default:
+		conn.Close(CloseInternalError, "")
+	}
+}
+
+func (h *messagesHandler[In, Receive, Send]) describe(api *Api) {
+	h.binder.describe(api)
+	messages := socketMessages{}
+	if api.socket != nil {
+		messages = *api.socket
+	}
+	if messages.receive == nil {
+		messages.receive = exampleOf(reflect.TypeFor[Receive]())
+	}
+	if messages.send == nil {
+		messages.send = exampleOf(reflect.TypeFor[Send]())
+	}
+	api.socket = &messages
+}
+
+func (h *messagesHandler[In, Receive, Send]) withHeartbeat(heartbeat time.Duration) http.Handler {
+	c := *h
+	c.heartbeat = heartbeat
+	return &c
+}
+
+func (h *messagesHandler[In, Receive, Send]) withCheckOrigin(checkOrigin func(r *http.Request) bool) http.Handler {
+	c := *h
+	c.checkOrigin = checkOrigin
+	return &c
+}
+
+// Context returns the context of the connection, it is canceled once the
+// connection is closed
+func (c *Conn[Receive, Send]) Context() context.Context {
+	return c.ctx
+}
+
+// Receive reads the next message of the client. It returns a *CloseError
+// once the client closed the connection, messages which are no valid JSON
+// return a *BindError and the connection stays open.
+func (c *Conn[Receive, Send]) Receive() (Receive, error) {
+	var message Receive
+	_, payload, err := c.conn.ReadMessage()
+	if err != nil {
+		c.cancel()
+		return message, err
+	}
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return message, &BindError{In: "message", Err: err}
+	}
+	return message, nil
+}
+
+// Send writes the message as JSON text message, it is safe to call Send
+// concurrently with Receive
+func (c *Conn[Receive, Send]) Send(message Send) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if err := c.conn.WriteMessage(websocket.OpText, payload); err != nil {
+		c.cancel()
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection with the status code and reason
+func (c *Conn[Receive, Send]) Close(code int, reason string) error {
+	c.cancel()
+	return c.conn.Close(code, reason)
+}
+
+// keepAlive pings the client until the connection is closed
+func (c *Conn[Receive, Send]) keepAlive(heartbeat time.Duration) {
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.conn.Ping(nil); err != nil {
+				c.cancel()
+				return
+			}
+		}
+	}
+}
+
+// isSocket reports if the Api upgrades to a websocket
+func (api Api) isSocket() bool {
+	return api.socket != nil
+}
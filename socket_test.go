@@ -0,0 +1,68 @@
+package web_test
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+func TestSocketOrigins(t *testing.T) {
+	w := newWeb()
+	handler := web.Messages(func(r *http.Request, in struct{}, conn *web.Conn[item, item]) error { return nil })
+	w.Socket(web.Socket{Path: "/events", Handler: handler})
+	w.Socket(web.Socket{
+		Path:        "/public",
+		Handler:     handler,
+		CheckOrigin: func(r *http.Request) bool { return r.Header.Get("Origin") == "https://app.example" },
+	})
+	server := httptest.NewServer(w.Server())
+	defer server.Close()
+
+	cases := []struct {
+		path   string
+		origin string
+		status int
+	}{
+		{"/events", "", http.StatusSwitchingProtocols},
+		{"/events", server.URL, http.StatusSwitchingProtocols},
+		{"/events", "https://evil.example", http.StatusForbidden},
+		{"/public", "https://app.example", http.StatusSwitchingProtocols},
+		{"/public", "https://evil.example", http.StatusForbidden},
+	}
+	for _, c := range cases {
+		if status := handshake(t, server, c.path, c.origin); status != c.status {
+			t.Errorf("%v from %q: expected %v, got %v", c.path, c.origin, c.status, status)
+		}
+	}
+}
+
+// handshake sends an opening handshake and returns the status of the response
+func handshake(t *testing.T, server *httptest.Server, path string, origin string) int {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	if err := r.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
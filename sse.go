@@ -0,0 +1,227 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Sse is a route streaming server-sent events
+type Sse struct {
+	Path        string
+	OperationId string
+	Summary     string
+	Description string
+	Parameter   Parameter
+	Security    []SecurityRequirement
+	Deprecated  bool
+	// Event is an example of the data of the events, handlers created with
+	// Events derive it from their type
+	Event any
+	// Heartbeat is the interval of the comments keeping idle connections
+	// alive, it defaults to 15 seconds. A negative value disables them.
+	Heartbeat time.Duration
+	Handler   http.Handler
+}
+
+// Event is a server-sent event, its Data is encoded as JSON
+type Event[T any] struct {
+	Id string
+	// Name is sent as the event field, unnamed events are message events
+	Name  string
+	Data  T
+	Retry time.Duration
+}
+
+const defaultHeartbeat = 15 * time.Second
+
+type eventsHandler[In, T any] struct {
+	handler   func(r *http.Request, in In) (iter.Seq[Event[T]], error)
+	binder    *binder
+	heartbeat time.Duration
+}
+
+// heartbeater is implemented by handlers created with Events
+type heartbeater interface {
+	withHeartbeat(heartbeat time.Duration) http.Handler
+}
+
+// Events creates a http.Handler which binds the request to In like Handle
+// and streams the events of the returned iterator. The stream ends when the
+// iterator is exhausted or the client disconnects, the iterator should
+// watch r.Context() while it waits for the next event.
+func Events[In, T any](h func(r *http.Request, in In) (iter.Seq[Event[T]], error)) http.Handler {
+	return &eventsHandler[In, T]{
+		handler:   h,
+		binder:    newBinder(reflect.TypeFor[In]()),
+		heartbeat: defaultHeartbeat,
+	}
+}
+
+// LastEventId returns the id of the last event a reconnecting client
+// received, the stream should resume after it
+func LastEventId(r *http.Request) string {
+	return r.Header.Get("Last-Event-ID")
+}
+
+func (g Group) Sse(sse Sse) {
+	assertIsNotEmpty("Sse.Path", sse.Path)
+	assertIsNotNil("Sse.Handler", sse.Handler)
+	handler := sse.Handler
+	if h, ok := handler.(heartbeater); ok && sse.Heartbeat != 0 {
+		handler = h.withHeartbeat(sse.Heartbeat)
+	}
+	api := Api{
+		Method:      http.MethodGet,
+		Path:        sse.Path,
+		OperationId: sse.OperationId,
+		Summary:     sse.Summary,
+		Description: sse.Description,
+		Parameter:   sse.Parameter,
+		Security:    sse.Security,
+		Deprecated:  sse.Deprecated,
+		Handler:     handler,
+	}
+	if sse.Event != nil {
+		api.Responses.StatusOK = ContentType{TextEventStream: sse.Event}
+	} else if _, ok := handler.(typedHandler); !ok {
+		api.Responses.StatusOK = ContentType{TextEventStream: ""}
+	}
+	g.Api(api)
+}
+
+func (h *eventsHandler[In, T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var in In
+	if err := h.binder.bind(r, reflect.ValueOf(&in).Elem()); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	events, err := h.handler(r, in)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	streamEvents(w, r, events, h.heartbeat)
+}
+
+func (h *eventsHandler[In, T]) describe(api *Api) {
+	h.binder.describe(api)
+	if api.Responses.StatusOK == nil {
+		api.Responses.StatusOK = ContentType{TextEventStream: exampleOf(reflect.TypeFor[T]())}
+	}
+}
+
+func (h *eventsHandler[In, T]) withHeartbeat(heartbeat time.Duration) http.Handler {
+	c := *h
+	c.heartbeat = heartbeat
+	return &c
+}
+
+func streamEvents[T any](w http.ResponseWriter, r *http.Request, events iter.Seq[Event[T]], heartbeat time.Duration) {
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := flush(rc); err != nil {
+		return
+	}
+
+	ctx := r.Context()
+	next := make(chan Event[T])
+	go func() {
+		defer close(next)
+		defer func() {
+			// the events run on their own goroutine, a panic would end the
+			// process instead of the request
+			if p := recover(); p != nil {
+				log.Printf("web: panic in the events of %v %v: %v\n%s", r.Method, r.URL.Path, p, debug.Stack())
+			}
+		}()
+		for event := range events {
+			select {
+			case next <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-next:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+		if err := flush(rc); err != nil {
+			return
+		}
+	}
+}
+
+// flush sends the buffered data to the client. Writers which can't flush,
+// like middlewares buffering the response, are not an error, the data is
+// sent once they are done.
+func flush(rc *http.ResponseController) error {
+	if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+func writeEvent[T any](w io.Writer, event Event[T]) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	b := &bytes.Buffer{}
+	if event.Id != "" {
+		fmt.Fprintf(b, "id: %v\n", singleLine(event.Id))
+	}
+	if event.Name != "" {
+		fmt.Fprintf(b, "event: %v\n", singleLine(event.Name))
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(b, "retry: %v\n", event.Retry.Milliseconds())
+	}
+	fmt.Fprintf(b, "data: %s\n\n", data)
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// singleLine removes line breaks which would end the field of an event
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// isEventStream reports if the Api responds with server-sent events
+func (api Api) isEventStream() bool {
+	c := isContentType(api.Responses.StatusOK)
+	return c != nil && c.TextEventStream != nil
+}
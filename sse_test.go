@@ -0,0 +1,79 @@
+package web_test
+
+import (
+	"io"
+	"iter"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+// unflushable hides the Flush of the wrapped writer like middlewares do
+// which don't unwrap
+type unflushable struct {
+	http.ResponseWriter
+}
+
+func withoutFlush(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(unflushable{w}, r)
+	})
+}
+
+func ticks(n int) iter.Seq[web.Event[item]] {
+	return func(yield func(web.Event[item]) bool) {
+		for i := range n {
+			if !yield(web.Event[item]{Id: string(rune('a' + i)), Name: "tick", Data: item{Name: "tick"}}) {
+				return
+			}
+		}
+	}
+}
+
+func TestEventsAreWrittenAsEventStream(t *testing.T) {
+	for _, flushable := range []bool{true, false} {
+		w := newWeb()
+		if !flushable {
+			w.Use(withoutFlush)
+		}
+		w.Api(web.Api{
+			Method: http.MethodGet,
+			Path:   "/ticks",
+			Handler: web.Events(func(r *http.Request, in struct{}) (iter.Seq[web.Event[item]], error) {
+				return ticks(2), nil
+			}),
+		})
+
+		resp, body := serve(t, w, httptest.NewRequest(http.MethodGet, "/ticks", nil))
+		expected := "id: a\nevent: tick\ndata: {\"name\":\"tick\"}\n\nid: b\nevent: tick\ndata: {\"name\":\"tick\"}\n\n"
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" || body != expected {
+			t.Errorf("flushable=%v: unexpected stream %v %q", flushable, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestPanickingEventsEndTheStream(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	w := newWeb()
+	w.Api(web.Api{
+		Method: http.MethodGet,
+		Path:   "/ticks",
+		Handler: web.Events(func(r *http.Request, in struct{}) (iter.Seq[web.Event[item]], error) {
+			return func(yield func(web.Event[item]) bool) {
+				if yield(web.Event[item]{Data: item{Name: "tick"}}) {
+					panic("broken iterator")
+				}
+			}, nil
+		}),
+	})
+
+	resp, body := serve(t, w, httptest.NewRequest(http.MethodGet, "/ticks", nil))
+	if resp.StatusCode != http.StatusOK || body != "data: {\"name\":\"tick\"}\n\n" {
+		t.Errorf("expected the stream to end after the panic, got %v %q", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,117 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"iter"
+	"log"
+	"net/http"
+	"reflect"
+)
+
+type streamHandler[In, T any] struct {
+	handler func(r *http.Request, in In) (iter.Seq[T], error)
+	binder  *binder
+}
+
+// Stream creates a http.Handler which binds the request to In like Handle
+// and streams the items of the returned iterator without buffering them. The
+// items are written as JSON array or, if the client accepts it, as
+// application/x-ndjson with one item per line. Every item is flushed, the
+// stream ends when the iterator is exhausted or the client disconnects.
+func Stream[In, T any](h func(r *http.Request, in In) (iter.Seq[T], error)) http.Handler {
+	return &streamHandler[In, T]{
+		handler: h,
+		binder:  newBinder(reflect.TypeFor[In]()),
+	}
+}
+
+func (h *streamHandler[In, T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var in In
+	if err := h.binder.bind(r, reflect.ValueOf(&in).Elem()); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	items, err := h.handler(r, in)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	streamItems(w, r, items, NegotiatedContentType(r) == "application/x-ndjson")
+}
+
+func (h *streamHandler[In, T]) describe(api *Api) {
+	h.binder.describe(api)
+	if api.Responses.StatusOK == nil {
+		items := exampleOf(reflect.TypeFor[[]T]())
+		api.Responses.StatusOK = ContentType{ApplicationJson: items, ApplicationXNdjson: items}
+	}
+}
+
+// streamItems encodes the items one by one. The status was already sent, so
+// an item failing to encode or a disconnected client aborts the response and
+// the client sees an incomplete body instead of a complete but truncated
+// JSON array.
+func streamItems[T any](w http.ResponseWriter, r *http.Request, items iter.Seq[T], ndjson bool) {
+	rc := http.NewResponseController(w)
+	if ndjson {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if !ndjson {
+		if _, err := io.WriteString(w, "["); err != nil {
+			panic(http.ErrAbortHandler)
+		}
+	}
+	if err := writeItems(r.Context(), w, rc, items, ndjson); err != nil {
+		if r.Context().Err() == nil {
+			log.Printf("web: stream of %v %v aborted: %v", r.Method, r.URL.Path, err)
+		}
+		panic(http.ErrAbortHandler)
+	}
+	if !ndjson {
+		if _, err := io.WriteString(w, "]"); err != nil {
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+// writeItems writes the items until they are exhausted, one fails to encode
+// or the context is done
+func writeItems[T any](ctx context.Context, w http.ResponseWriter, rc *http.ResponseController, items iter.Seq[T], ndjson bool) error {
+	separator := ""
+	for item := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if ndjson {
+			data = append(data, '\n')
+		} else {
+			data = append([]byte(separator), data...)
+			separator = ","
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if err := flush(rc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isStreamed reports if the Api streams its response, streamed responses are
+// not buffered to validate them
+func (api Api) isStreamed() bool {
+	c := isContentType(api.Responses.StatusOK)
+	return c != nil && (c.TextEventStream != nil || c.ApplicationXNdjson != nil)
+}
@@ -0,0 +1,99 @@
+package web_test
+
+import (
+	"encoding/json"
+	"io"
+	"iter"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+func items(names ...string) iter.Seq[item] {
+	return func(yield func(item) bool) {
+		for _, name := range names {
+			if !yield(item{Name: name}) {
+				return
+			}
+		}
+	}
+}
+
+func newStreamWeb(flushable bool, names ...string) *web.Web {
+	w := newWeb()
+	if !flushable {
+		w.Use(withoutFlush)
+	}
+	w.Api(web.Api{
+		Method: http.MethodGet,
+		Path:   "/items",
+		Handler: web.Stream(func(r *http.Request, in struct{}) (iter.Seq[item], error) {
+			return items(names...), nil
+		}),
+	})
+	return w
+}
+
+func TestStreamWritesJsonArrays(t *testing.T) {
+	for _, flushable := range []bool{true, false} {
+		for _, names := range [][]string{nil, {"a", "b", "c"}} {
+			resp, body := serve(t, newStreamWeb(flushable, names...), httptest.NewRequest(http.MethodGet, "/items", nil))
+			decoded := []item{}
+			if err := json.Unmarshal([]byte(body), &decoded); err != nil || len(decoded) != len(names) {
+				t.Errorf("flushable=%v: expected a JSON array of %v items, got %q", flushable, len(names), body)
+			}
+			if resp.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("unexpected content type %v", resp.Header.Get("Content-Type"))
+			}
+		}
+	}
+}
+
+func TestStreamWritesNdjson(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/items", nil)
+	r.Header.Set("Accept", "application/x-ndjson")
+	resp, body := serve(t, newStreamWeb(false, "a", "b"), r)
+	if resp.Header.Get("Content-Type") != "application/x-ndjson" || body != "{\"name\":\"a\"}\n{\"name\":\"b\"}\n" {
+		t.Errorf("unexpected NDJSON stream %v %q", resp.Header.Get("Content-Type"), body)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/items", nil)
+	r.Header.Set("Accept", "text/html")
+	if resp, _ := serve(t, newStreamWeb(true), r); resp.StatusCode != http.StatusNotAcceptable {
+		t.Errorf("expected unsupported media types to be refused, got %v", resp.StatusCode)
+	}
+}
+
+func TestStreamAbortsOnEncodeErrors(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	w := newWeb()
+	w.Api(web.Api{
+		Method: http.MethodGet,
+		Path:   "/numbers",
+		Handler: web.Stream(func(r *http.Request, in struct{}) (iter.Seq[float64], error) {
+			return slices.Values([]float64{1, math.NaN(), 3}), nil
+		}),
+	})
+	server := httptest.NewServer(w.Server())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/numbers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err == nil {
+		t.Errorf("expected the response to be aborted, got %q", body)
+	}
+	if json.Valid(body) {
+		t.Errorf("expected no valid JSON array, got %q", body)
+	}
+}
@@ -0,0 +1,230 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Instantan/web/internal/openapi"
+)
+
+type Validation struct {
+	// Requests rejects requests which do not match the parameters and the
+	// request body of the operation
+	Requests bool
+	// Responses checks every response against the declared Responses of the
+	// operation. This buffers the whole response and is meant for development
+	// and tests.
+	Responses bool
+	// Strict replaces mismatching responses with a 500 listing the violations
+	Strict bool
+	// OnResponseViolation gets called for every mismatching response, it
+	// defaults to logging the violations
+	OnResponseViolation func(r *http.Request, violations []Violation)
+	// MaxBodySize limits the request bodies read for validation, larger
+	// bodies are answered with 413. It defaults to 10 MB, multipart bodies
+	// are limited by the Multipart of the Web instead.
+	MaxBodySize int64
+}
+
+const defaultMaxBodySize = 10 << 20
+
+type Violation struct {
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+func (g Group) Validation(validation Validation) {
+	*g.routes = append(*g.routes, route{
+		validation: &validation,
+	})
+}
+
+func validateRequests(operation *openapi.Operation, components *openapi.Components, validation Validation) Use {
+	maxBodySize := validation.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status := http.StatusBadRequest
+			violations := []openapi.Violation{}
+			for _, parameter := range operation.Parameters {
+				location := parameter.In + "." + parameter.Name
+				values := parameterValues(r, parameter.In, parameter.Name)
+				if len(values) == 0 {
+					if parameter.Required {
+						violations = append(violations, openapi.Violation{Location: location, Message: "is required"})
+					}
+					continue
+				}
+				for _, value := range values {
+					violations = append(violations, parameter.Schema.ValidateString(value, components.Schemas, location)...)
+				}
+			}
+			if operation.RequestBody != nil && !isMultipart(r) {
+				body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+				if err != nil {
+					if maxBytesError := (*http.MaxBytesError)(nil); errors.As(err, &maxBytesError) {
+						WriteProblem(w, r, NewProblem(http.StatusRequestEntityTooLarge, fmt.Sprintf("body exceeds %v bytes", maxBodySize)))
+						return
+					}
+					WriteProblem(w, r, NewProblem(http.StatusBadRequest, err.Error()))
+					return
+				}
+				// keep empty bodies recognizable as missing for the binding
+				r.Body = http.NoBody
+				if len(body) > 0 {
+					r.Body = io.NopCloser(bytes.NewReader(body))
+				}
+
+				bodyViolations, syntaxError := validateBody(operation.RequestBody, components, r.Header.Get("Content-Type"), body)
+				if len(violations) == 0 && !syntaxError {
+					status = http.StatusUnprocessableEntity
+				}
+				violations = append(violations, bodyViolations...)
+			}
+			if len(violations) > 0 {
+				writeViolations(w, r, status, violations)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isMultipart reports multipart bodies, they are not buffered for validation
+func isMultipart(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return strings.HasPrefix(mediaType, "multipart/")
+}
+
+func validateBody(requestBody *openapi.RequestBody, components *openapi.Components, contentType string, body []byte) ([]openapi.Violation, bool) {
+	if len(body) == 0 {
+		if requestBody.Required {
+			return []openapi.Violation{{Location: "body", Message: "is required"}}, true
+		}
+		return nil, false
+	}
+	mediaType := "application/json"
+	if contentType != "" {
+		mediaType, _, _ = mime.ParseMediaType(contentType)
+	}
+	content, ok := requestBody.Content[mediaType]
+	if !ok || !isJsonMediaType(mediaType) {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []openapi.Violation{{Location: "body", Message: "is not valid json"}}, true
+	}
+	return content.Schema.Validate(value, components.Schemas, "body"), false
+}
+
+func validateResponses(operation *openapi.Operation, components *openapi.Components, validation Validation) Use {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bw := &bufferedResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(bw, r)
+
+			violations := validateResponse(operation, components, bw.status, w.Header().Get("Content-Type"), bw.body.Bytes())
+			if len(violations) > 0 {
+				res := make([]Violation, len(violations))
+				for i, violation := range violations {
+					res[i] = Violation(violation)
+				}
+				if validation.OnResponseViolation != nil {
+					validation.OnResponseViolation(r, res)
+				} else {
+					log.Printf("web: response of %v %v does not match its declaration: %v", r.Method, r.URL.Path, violations)
+				}
+				if validation.Strict {
+					w.Header().Del("Content-Length")
+					writeViolations(w, r, http.StatusInternalServerError, violations)
+					return
+				}
+			}
+			w.WriteHeader(bw.status)
+			w.Write(bw.body.Bytes())
+		})
+	}
+}
+
+func validateResponse(operation *openapi.Operation, components *openapi.Components, status int, contentType string, body []byte) []openapi.Violation {
+	response, ok := operation.Responses.HTTPStatusCodeResponses[strconv.Itoa(status)]
+	if !ok {
+		if operation.Responses.Default.Content == nil {
+			if len(operation.Responses.HTTPStatusCodeResponses) == 0 {
+				return nil
+			}
+			return []openapi.Violation{{Location: "status", Message: strconv.Itoa(status) + " is not a declared response status"}}
+		}
+		response = operation.Responses.Default
+	}
+	if len(response.Content) == 0 || len(body) == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	content, ok := response.Content[mediaType]
+	if !ok {
+		return []openapi.Violation{{Location: "content-type", Message: "\"" + contentType + "\" is not a declared content type"}}
+	}
+	if !isJsonMediaType(mediaType) {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []openapi.Violation{{Location: "body", Message: "is not valid json"}}
+	}
+	return content.Schema.Validate(value, components.Schemas, "body")
+}
+
+func isJsonMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+}
+
+func (w *bufferedResponseWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	return w.body.Write(p)
+}
+
+func (w *bufferedResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func writeViolations(w http.ResponseWriter, r *http.Request, status int, violations []openapi.Violation) {
+	WriteProblem(w, r, violationsProblem(status, violations))
+}
+
+func violationsProblem(status int, violations []openapi.Violation) *Problem {
+	res := make([]Violation, len(violations))
+	for i, violation := range violations {
+		res[i] = Violation(violation)
+	}
+	return NewProblem(status, "").With("violations", res)
+}
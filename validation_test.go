@@ -0,0 +1,158 @@
+package web_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Instantan/web"
+)
+
+type item struct {
+	Name string `json:"name"`
+}
+
+func TestValidationKeepsOptionalBodiesMissing(t *testing.T) {
+	w := newWeb()
+	w.Validation(web.Validation{Requests: true})
+	w.Api(web.Api{
+		Method: http.MethodPost,
+		Path:   "/items",
+		Handler: web.Handle(func(r *http.Request, in struct{ Body *item }) (string, error) {
+			if in.Body == nil {
+				return "none", nil
+			}
+			return in.Body.Name, nil
+		}),
+	})
+
+	resp, body := serve(t, w, httptest.NewRequest(http.MethodPost, "/items", nil))
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `"none"`) {
+		t.Errorf("expected the missing optional body to be accepted, got %v %v", resp.StatusCode, body)
+	}
+	resp, body = serve(t, w, httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name":"a"}`)))
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `"a"`) {
+		t.Errorf("expected the body to be bound, got %v %v", resp.StatusCode, body)
+	}
+}
+
+func TestValidationLimitsBodies(t *testing.T) {
+	w := newWeb()
+	w.Validation(web.Validation{Requests: true, MaxBodySize: 8})
+	w.Api(web.Api{
+		Method:  http.MethodPost,
+		Path:    "/items",
+		Handler: web.Handle(func(r *http.Request, in struct{ Body item }) (item, error) { return in.Body, nil }),
+	})
+
+	resp, _ := serve(t, w, httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"name":"too long"}`)))
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %v", resp.StatusCode)
+	}
+}
+
+func TestValidationDocumentsOnlyPossibleProblems(t *testing.T) {
+	w := newWeb()
+	w.Validation(web.Validation{Requests: true})
+	w.Api(web.Api{
+		Method:    http.MethodGet,
+		Path:      "/health",
+		Responses: web.Responses{StatusOK: "ok"},
+		Handler:   http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	})
+	w.Api(web.Api{
+		Method:    http.MethodPost,
+		Path:      "/items",
+		Parameter: web.Parameter{Body: web.Body{Value: item{}}},
+		Responses: web.Responses{StatusOK: item{}},
+		Handler:   http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	})
+	doc := spec(t, w)
+
+	health := responses(t, doc, "/health", http.MethodGet)
+	if health["400"] != nil || health["422"] != nil {
+		t.Errorf("expected no validation problems without parameters and body, got %v", health)
+	}
+	items := responses(t, doc, "/items", http.MethodPost)
+	if items["400"] == nil || items["422"] == nil {
+		t.Errorf("expected the validation problems of the body, got %v", items)
+	}
+}
+
+func TestImpliedFormatsDocumentNoUnprocessableEntity(t *testing.T) {
+	type event struct {
+		At time.Time `json:"at"`
+	}
+	type booking struct {
+		At string `json:"at" validate:"format=date-time"`
+	}
+	w := newWeb()
+	w.Api(web.Api{
+		Method:  http.MethodPost,
+		Path:    "/events",
+		Handler: web.Handle(func(r *http.Request, in struct{ Body event }) (string, error) { return "", nil }),
+	})
+	w.Api(web.Api{
+		Method:  http.MethodPost,
+		Path:    "/bookings",
+		Handler: web.Handle(func(r *http.Request, in struct{ Body booking }) (string, error) { return "", nil }),
+	})
+	doc := spec(t, w)
+
+	if events := responses(t, doc, "/events", http.MethodPost); events["422"] != nil {
+		t.Errorf("expected no 422 for a body without validate tags, got %v", events)
+	}
+	if bookings := responses(t, doc, "/bookings", http.MethodPost); bookings["422"] == nil {
+		t.Errorf("expected a 422 for a body with validate tags, got %v", bookings)
+	}
+}
+
+func TestBindingAcceptsOmittedOptionalFields(t *testing.T) {
+	type ref struct {
+		Id string `json:"id"`
+	}
+	type node struct {
+		Name   string   `json:"name" validate:"minLength=1"`
+		Parent *ref     `json:"parent"`
+		Tags   []string `json:"tags"`
+		Labels []string `json:"labels" validate:"required"`
+	}
+	w := newWeb()
+	w.Api(web.Api{
+		Method:  http.MethodPost,
+		Path:    "/nodes",
+		Handler: web.Handle(func(r *http.Request, in struct{ Body node }) (node, error) { return in.Body, nil }),
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/nodes", strings.NewReader(`{"name":"a","labels":[]}`))
+	r.Header.Set("Content-Type", "application/json")
+	if resp, body := serve(t, w, r); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected omitted pointer and slice fields to be valid, got %v %v", resp.StatusCode, body)
+	}
+	r = httptest.NewRequest(http.MethodPost, "/nodes", strings.NewReader(`{"name":"a"}`))
+	r.Header.Set("Content-Type", "application/json")
+	if resp, body := serve(t, w, r); resp.StatusCode != http.StatusUnprocessableEntity || !strings.Contains(body, "body.labels") {
+		t.Errorf("expected the required slice to be rejected, got %v %v", resp.StatusCode, body)
+	}
+}
+
+func TestResponseValidationAcceptsNilFields(t *testing.T) {
+	type page struct {
+		Next  *item  `json:"next"`
+		Items []item `json:"items"`
+	}
+	w := newWeb()
+	w.Validation(web.Validation{Responses: true, Strict: true})
+	w.Api(web.Api{
+		Method:  http.MethodGet,
+		Path:    "/items",
+		Handler: web.Handle(func(r *http.Request, in struct{}) (page, error) { return page{}, nil }),
+	})
+
+	resp, body := serve(t, w, httptest.NewRequest(http.MethodGet, "/items", nil))
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(body) != `{"next":null,"items":null}` {
+		t.Errorf("expected the nil pointer and slice to pass the response validation, got %v %v", resp.StatusCode, body)
+	}
+}
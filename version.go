@@ -0,0 +1,94 @@
+package web
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Instantan/web/internal/openapi"
+)
+
+type Version struct {
+	// Name is referenced by Group.Version and Api.Versions, e.g. v1
+	Name string
+	// Version is used as Info.Version of the spec, it defaults to Name
+	Version string
+	// Deprecated marks every operation of the version as deprecated
+	Deprecated bool
+	// DeprecatedAt and Sunset are the dates the operations of the version are
+	// deprecated at and removed after, they are sent as headers like the ones
+	// of an Api and imply Deprecated
+	DeprecatedAt  time.Time
+	Sunset        time.Time
+	OpenApi       OpenApi
+	AsyncApi      AsyncApi
+	TypescriptApi *TypescriptApi
+}
+
+type versionedOperation struct {
+	path         string
+	method       string
+	operation    *openapi.Operation
+	servers      []openapi.Server
+	version      string
+	versions     []string
+	deprecatedIn []string
+}
+
+// Version assigns all following routes of the group to the version
+func (g Group) Version(name string) {
+	assertIsNotEmpty("Group.Version", name)
+	*g.routes = append(*g.routes, route{
+		version: &name,
+	})
+}
+
+func assertIsValidVersion(version Version) {
+	assertIsNotEmpty("Version.Name", version.Name)
+	if version.OpenApi.DocPath != "" || version.OpenApi.UiPath != "" {
+		assertIsValidOpenApi(version.OpenApi)
+	}
+}
+
+func assertIsDeclaredVersion(operations []versionedOperation, versions []Version) {
+	declared := []string{}
+	for _, version := range versions {
+		declared = append(declared, version.Name)
+	}
+	for _, operation := range operations {
+		for _, name := range append(append([]string{operation.version}, operation.versions...), operation.deprecatedIn...) {
+			if name != "" && !slices.Contains(declared, name) {
+				panic(fmt.Errorf("version %v of %v %v is not declared", name, operation.method, operation.path))
+			}
+		}
+	}
+}
+
+func (version Version) isDeprecated() bool {
+	return version.Deprecated || !version.DeprecatedAt.IsZero() || !version.Sunset.IsZero()
+}
+
+func (o versionedOperation) documentedIn(version string) bool {
+	return o.version == version || slices.Contains(o.versions, version) || slices.Contains(o.deprecatedIn, version)
+}
+
+// versionPaths returns the paths of all operations documented in the version
+func versionPaths(operations []versionedOperation, version Version) openapi.Paths {
+	paths := openapi.Paths{}
+	for _, o := range operations {
+		if !o.documentedIn(version.Name) {
+			continue
+		}
+		operation := *o.operation
+		if version.isDeprecated() || slices.Contains(o.deprecatedIn, version.Name) {
+			operation.Deprecated = true
+		}
+		p, _ := paths.Get(o.path)
+		if len(o.servers) > 0 {
+			p.Servers = o.servers
+		}
+		setOperation(&p, o.method, &operation)
+		paths.Set(o.path, p)
+	}
+	return paths
+}
@@ -0,0 +1,47 @@
+package web_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+func TestVersionsHaveTheirOwnSpec(t *testing.T) {
+	w := newWeb()
+	w.Version(web.Version{Name: "v1", Version: "1.4.0", OpenApi: web.OpenApi{DocPath: "/v1/doc.json", UiPath: "/v1/doc", UiVariant: "scalar"}})
+	w.Version(web.Version{Name: "v2", OpenApi: web.OpenApi{DocPath: "/v2/doc.json", UiPath: "/v2/doc", UiVariant: "scalar"}})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	w.Group(func(g web.Group) {
+		g.Version("v1")
+		g.Api(web.Api{Method: http.MethodGet, Path: "/users", DeprecatedIn: []string{"v2"}, Handler: handler})
+		g.Api(web.Api{Method: http.MethodGet, Path: "/groups", Versions: []string{"v2"}, Handler: handler})
+		g.Api(web.Api{Method: http.MethodGet, Path: "/legacy", Handler: handler})
+	})
+	w.Group(func(g web.Group) {
+		g.Version("v2")
+		g.Api(web.Api{Method: http.MethodGet, Path: "/roles", Handler: handler})
+	})
+
+	v1 := specAt(t, w, "/v1/doc.json")
+	if lookup(v1, "info", "version") != "1.4.0" {
+		t.Errorf("expected the version of v1, got %v", lookup(v1, "info", "version"))
+	}
+	for path, documented := range map[string]bool{"/users": true, "/groups": true, "/legacy": true, "/roles": false} {
+		if (lookup(v1, "paths", path) != nil) != documented {
+			t.Errorf("expected %v to be documented in v1: %v", path, documented)
+		}
+	}
+	v2 := specAt(t, w, "/v2/doc.json")
+	if lookup(v2, "info", "version") != "v2" {
+		t.Errorf("expected the version to default to the name, got %v", lookup(v2, "info", "version"))
+	}
+	for path, documented := range map[string]bool{"/users": true, "/groups": true, "/legacy": false, "/roles": true} {
+		if (lookup(v2, "paths", path) != nil) != documented {
+			t.Errorf("expected %v to be documented in v2: %v", path, documented)
+		}
+	}
+	if lookup(v2, "paths", "/users", "get", "deprecated") != true || lookup(v1, "paths", "/users", "get", "deprecated") == true {
+		t.Error("expected /users to be deprecated in v2 only")
+	}
+}
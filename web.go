@@ -15,7 +15,13 @@ type Web struct {
 	license               *License
 	externalDocumentation ExternalDocumentation
 	openapi               OpenApi
+	asyncapi              AsyncApi
 	typescriptApi         *TypescriptApi
+	securitySchemes       []SecurityScheme
+	servers               []Server
+	versions              []Version
+	webhooks              []Webhook
+	codecs                *codecs
 
 	group Group
 }
@@ -62,6 +68,7 @@ type TypescriptApi struct {
 
 func NewWeb() *Web {
 	return &Web{
+		codecs: newCodecs(),
 		group: Group{
 			routes: &[]route{},
 		},
@@ -92,21 +99,67 @@ func (web *Web) ExternalDocumentation(externalDocumentation ExternalDocumentatio
 }
 
 func (web *Web) OpenApi(openapi OpenApi) {
-	assertIsNotEmpty("OpenApi.DocPath", openapi.DocPath)
-	assertIsNotEmpty("OpenApi.UiPath", openapi.UiPath)
-	assertIsNotEmpty("OpenApi.UiVariant", openapi.UiVariant)
-	assertIsOneOf(openapi.UiVariant, []string{"scalar", "swagger", "redoc"})
+	assertIsValidOpenApi(openapi)
 	web.openapi = openapi
 }
 
+func (web *Web) Version(version Version) {
+	assertIsValidVersion(version)
+	web.versions = append(web.versions, version)
+}
+
+func (web *Web) Servers(servers ...Server) {
+	for _, server := range servers {
+		assertIsValidServer(server)
+	}
+	web.servers = append(web.servers, servers...)
+}
+
+func (web *Web) SecurityScheme(securityScheme SecurityScheme) {
+	assertIsValidSecurityScheme(securityScheme)
+	web.securitySchemes = append(web.securitySchemes, securityScheme)
+}
+
+func (web *Web) Security(security ...SecurityRequirement) {
+	web.group.Security(security...)
+}
+
+// Codec registers the codec for the media type. Typed handlers decode request
+// bodies and encode responses with every registered codec which supports their
+// types, application/json is registered by default.
+func (web *Web) Codec(mediaType string, codec Codec) {
+	assertIsValidCodec(mediaType, codec)
+	web.codecs.register(mediaType, codec)
+}
+
+// Multipart sets the size limits of multipart/form-data bodies
+func (web *Web) Multipart(multipart Multipart) {
+	if multipart.MaxMemory == 0 {
+		multipart.MaxMemory = defaultMultipart.MaxMemory
+	}
+	web.codecs.multipart = multipart
+}
+
 func (web *Web) Use(use Use) {
 	web.group.Use(use)
 }
 
+func (web *Web) Validation(validation Validation) {
+	web.group.Validation(validation)
+}
+
 func (web *Web) Api(route Api) {
 	web.group.Api(route)
 }
 
+func (web *Web) Sse(sse Sse) {
+	web.group.Sse(sse)
+}
+
+func (web *Web) Socket(socket Socket) {
+	web.group.Socket(socket)
+}
+
 func (web *Web) Static(static Static) {
 	web.group.Static(static)
 }
@@ -115,6 +168,10 @@ func (web *Web) Group(group func(Group)) {
 	web.group.Group(group)
 }
 
+func (web *Web) Mount(prefix string, group func(Group)) {
+	web.group.Mount(prefix, group)
+}
+
 func (web *Web) TypescriptApi(typescriptApi TypescriptApi) {
 	web.typescriptApi = &typescriptApi
 }
@@ -134,6 +191,16 @@ func (web *Web) Server() http.Handler {
 		Callbacks:       map[string]openapi.Callback{},
 		PathItems:       map[string]openapi.PathItem{},
 	}
+	schemes := map[string]SecurityScheme{}
+	for _, securityScheme := range web.securitySchemes {
+		schemes[securityScheme.Name] = securityScheme
+		components.SecuritySchemes[securityScheme.Name] = securityScheme.openapiSecurityScheme()
+	}
+	components.Schemas["Problem"] = problemSchema()
+	components.Responses["Problem"] = openapi.Response{
+		Description: "Problem details as defined in RFC 9457",
+		Content:     problemResponse(http.StatusInternalServerError).Content,
+	}
 
 	tags := &tags{
 		tags:       &map[string]Tag{},
@@ -143,10 +210,20 @@ func (web *Web) Server() http.Handler {
 	oa := openapi.OpenAPI{}
 	oa.OpenApi = "3.1.0"
 	oa.Info = web.info.openapiInfo()
-	oa.Paths = *web.group.openapiPaths(mux, components, nil, tags)
+	versioned := &[]versionedOperation{}
+	oa.Paths = *web.group.openapiPaths(mux, components, scope{tags: tags, schemes: schemes, versions: web.versions, versioned: versioned, codecs: web.codecs})
+	if len(web.webhooks) > 0 {
+		oa.Webhooks = map[string]openapi.PathItem{}
+		for _, webhook := range web.webhooks {
+			oa.Webhooks[webhook.Name] = webhook.openapiPathItem(components, true)
+		}
+	}
 	oa.Components = *components
 	oa.Tags = tags.openapiTags()
 	oa.Servers = []openapi.Server{}
+	if len(web.servers) > 0 {
+		oa.Servers = openapiServers(web.servers)
+	}
 
 	if web.contact != nil {
 		oa.Info.Contact = web.contact.openapiContact()
@@ -155,36 +232,24 @@ func (web *Web) Server() http.Handler {
 		oa.Info.License = web.license.openapiLicense()
 	}
 
-	schema, err := json.Marshal(oa)
-	if err != nil {
-		panic(err)
-	}
-
-	if web.openapi.DocPath != "" {
-		mux.HandleFunc(http.MethodGet+" "+web.openapi.DocPath, func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("content-type", "application/json")
-			w.WriteHeader(200)
-			w.Write(schema)
-		})
-	}
-	if web.openapi.DocPath != "" && web.openapi.UiPath != "" {
-		mux.Handle(http.MethodGet+" "+web.openapi.UiPath, web.openapi.httpHandler(web.info.Title))
-	}
+	web.openapi.serve(mux, oa)
+	web.asyncapi.serve(mux, oa)
+	web.typescriptApi.write(oa)
 
-	if web.typescriptApi != nil {
-		if web.typescriptApi.Writer == nil {
-			web.typescriptApi.Writer, err = openOrCreateFile(web.typescriptApi.Path)
-			if err != nil {
-				panic(err)
-			}
-		}
-		_, err := web.typescriptApi.Writer.Write(generate.GenerateTypescriptModels(oa))
-		if err != nil {
-			panic(err)
+	assertIsDeclaredVersion(*versioned, web.versions)
+	for _, version := range web.versions {
+		voa := oa
+		voa.Info.Version = version.Version
+		if voa.Info.Version == "" {
+			voa.Info.Version = version.Name
 		}
+		voa.Paths = versionPaths(*versioned, version)
+		version.OpenApi.serve(mux, voa)
+		version.AsyncApi.serve(mux, voa)
+		version.TypescriptApi.write(voa)
 	}
 
-	return mux
+	return problemFallback(mux)
 }
 
 func (info Info) openapiInfo() openapi.Info {
@@ -211,6 +276,48 @@ func (license License) openapiLicense() *openapi.License {
 	}
 }
 
+func assertIsValidOpenApi(openapi OpenApi) {
+	assertIsNotEmpty("OpenApi.DocPath", openapi.DocPath)
+	assertIsNotEmpty("OpenApi.UiPath", openapi.UiPath)
+	assertIsNotEmpty("OpenApi.UiVariant", openapi.UiVariant)
+	assertIsOneOf(openapi.UiVariant, []string{"scalar", "swagger", "redoc"})
+}
+
+func (openapi OpenApi) serve(mux *http.ServeMux, oa openapi.OpenAPI) {
+	if openapi.DocPath == "" {
+		return
+	}
+	schema, err := json.Marshal(oa)
+	if err != nil {
+		panic(err)
+	}
+	mux.HandleFunc(http.MethodGet+" "+openapi.DocPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(200)
+		w.Write(schema)
+	})
+	if openapi.UiPath != "" {
+		mux.Handle(http.MethodGet+" "+openapi.UiPath, openapi.httpHandler(oa.Info.Title))
+	}
+}
+
+func (typescriptApi *TypescriptApi) write(oa openapi.OpenAPI) {
+	if typescriptApi == nil {
+		return
+	}
+	if typescriptApi.Writer == nil {
+		var err error
+		typescriptApi.Writer, err = openOrCreateFile(typescriptApi.Path)
+		if err != nil {
+			panic(err)
+		}
+	}
+	_, err := typescriptApi.Writer.Write(generate.GenerateTypescriptModels(oa))
+	if err != nil {
+		panic(err)
+	}
+}
+
 func (openapi OpenApi) httpHandler(title string) http.Handler {
 	switch openapi.UiVariant {
 	case "redoc":
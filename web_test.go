@@ -0,0 +1,68 @@
+package web_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Instantan/web"
+)
+
+// newWeb returns a Web serving its spec at /openapi.json
+func newWeb() *web.Web {
+	w := web.NewWeb()
+	w.Info(web.Info{Title: "Test", Version: "1"})
+	w.OpenApi(web.OpenApi{DocPath: "/openapi.json", UiPath: "/docs", UiVariant: "scalar"})
+	return w
+}
+
+// serve sends the request to the server of the Web and returns the response
+// with its body read
+func serve(t *testing.T, w *web.Web, r *http.Request) (*http.Response, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	w.Server().ServeHTTP(rec, r)
+	body, _ := io.ReadAll(rec.Result().Body)
+	return rec.Result(), string(body)
+}
+
+// spec returns the decoded OpenAPI spec of the Web
+func spec(t *testing.T, w *web.Web) map[string]any {
+	t.Helper()
+	return specAt(t, w, "/openapi.json")
+}
+
+// specAt returns the decoded OpenAPI spec served at the path
+func specAt(t *testing.T, w *web.Web, path string) map[string]any {
+	t.Helper()
+	_, body := serve(t, w, httptest.NewRequest(http.MethodGet, path, nil))
+	doc := map[string]any{}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("spec is no JSON: %v\n%v", err, body)
+	}
+	return doc
+}
+
+// responses returns the responses of the operation in the spec
+func responses(t *testing.T, doc map[string]any, path string, method string) map[string]any {
+	t.Helper()
+	operation, ok := lookup(doc, "paths", path, strings.ToLower(method)).(map[string]any)
+	if !ok {
+		t.Fatalf("operation %v %v is not documented", method, path)
+	}
+	return operation["responses"].(map[string]any)
+}
+
+func lookup(value any, keys ...string) any {
+	for _, key := range keys {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
@@ -0,0 +1,267 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Instantan/web/internal/openapi"
+)
+
+// Webhook is a request the Web sends to its subscribers. Declared with
+// Web.Webhook it is documented in the webhooks of the spec, Send delivers it.
+type Webhook struct {
+	// Name identifies the webhook in the spec, e.g. orderCreated
+	Name        string
+	Method      string
+	OperationId string
+	Summary     string
+	Description string
+	Deprecated  bool
+	// Payload is an example of the JSON body sent to the subscribers
+	Payload any
+	// Responses are the responses expected from the subscribers, it defaults
+	// to an empty 200
+	Responses Responses
+	// Retry configures the redelivery of failed requests
+	Retry Retry
+	// Client sends the requests, it defaults to a client with a 10 second
+	// timeout
+	Client *http.Client
+}
+
+// Retry redelivers requests which failed with a network error, 429 or a 5xx
+// status. The delay starts at Backoff and doubles after every attempt.
+type Retry struct {
+	// Attempts is the maximum number of deliveries, it defaults to 5
+	Attempts int
+	// Backoff is the delay before the second attempt, it defaults to 1 second
+	Backoff time.Duration
+	// MaxBackoff limits the delay between attempts, it defaults to 1 minute
+	MaxBackoff time.Duration
+}
+
+const (
+	// WebhookSignatureHeader carries the HMAC-SHA256 signature of the
+	// timestamp, the id and the body, see WebhookSignature
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	// WebhookIdHeader carries the id of the delivery, it is the same for
+	// every attempt so subscribers can detect redeliveries
+	WebhookIdHeader = "X-Webhook-Id"
+	// WebhookTimestampHeader carries the unix time in seconds the attempt was
+	// sent at
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	// WebhookTolerance is the age after which VerifyWebhook rejects a
+	// delivery as replayed
+	WebhookTolerance = 5 * time.Minute
+)
+
+var defaultWebhookClient = &http.Client{Timeout: 10 * time.Second}
+
+func (web *Web) Webhook(webhook Webhook) {
+	assertIsNotEmpty("Webhook.Name", webhook.Name)
+	assertIsNotNil("Webhook.Payload", webhook.Payload)
+	if webhook.Method != "" {
+		assertIsOneOf(strings.ToUpper(webhook.Method), []string{http.MethodPost, http.MethodPut, http.MethodPatch})
+	}
+	web.webhooks = append(web.webhooks, webhook)
+}
+
+// Send delivers the payload as JSON to the url. With a secret the body is
+// signed, the signature is sent as WebhookSignatureHeader. Failed deliveries
+// are retried until the attempts are exhausted or the context is done.
+func (webhook Webhook) Send(ctx context.Context, url string, secret []byte, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	random := make([]byte, 16)
+	rand.Read(random)
+	id := hex.EncodeToString(random)
+	retry := webhook.Retry.withDefaults()
+	client := webhook.Client
+	if client == nil {
+		client = defaultWebhookClient
+	}
+
+	backoff := retry.Backoff
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, webhook.method(), url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		timestamp := time.Now().Unix()
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(WebhookIdHeader, id)
+		req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+		if len(secret) > 0 {
+			req.Header.Set(WebhookSignatureHeader, WebhookSignature(secret, timestamp, id, body))
+		}
+
+		delay := backoff
+		resp, err := client.Do(req)
+		if err == nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				return nil
+			}
+			err = fmt.Errorf("subscriber responded with %v", resp.Status)
+			if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+				return fmt.Errorf("webhook %v: %w", webhook.Name, err)
+			}
+			if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds >= 0 {
+				// subscribers may slow down the retries but never stall them
+				delay = min(time.Duration(seconds)*time.Second, retry.MaxBackoff)
+			}
+		}
+		if attempt >= retry.Attempts {
+			return fmt.Errorf("webhook %v: delivery failed after %v attempts: %w", webhook.Name, attempt, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, retry.MaxBackoff)
+	}
+}
+
+// WebhookSignature returns the signature sent as WebhookSignatureHeader. It
+// signs timestamp.id.body, so a captured delivery can't be replayed with a
+// later timestamp or another id.
+func WebhookSignature(secret []byte, timestamp int64, id string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d.%s.", timestamp, id)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook reads the body of a delivery and verifies its signature.
+// Deliveries older than the tolerance are rejected as replayed, it defaults
+// to WebhookTolerance. Subscribers should additionally remember the ids of
+// the handled deliveries for the tolerance to ignore redeliveries.
+func VerifyWebhook(r *http.Request, secret []byte, tolerance time.Duration) ([]byte, error) {
+	if tolerance <= 0 {
+		tolerance = WebhookTolerance
+	}
+	timestamp, err := strconv.ParseInt(r.Header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("webhook: invalid %v", WebhookTimestampHeader)
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return nil, fmt.Errorf("webhook: timestamp is outside the tolerance of %v", tolerance)
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	expected := WebhookSignature(secret, timestamp, r.Header.Get(WebhookIdHeader), body)
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get(WebhookSignatureHeader))) {
+		return nil, fmt.Errorf("webhook: invalid signature")
+	}
+	return body, nil
+}
+
+func (webhook Webhook) method() string {
+	if webhook.Method == "" {
+		return http.MethodPost
+	}
+	return strings.ToUpper(webhook.Method)
+}
+
+func (retry Retry) withDefaults() Retry {
+	if retry.Attempts <= 0 {
+		retry.Attempts = 5
+	}
+	if retry.Backoff <= 0 {
+		retry.Backoff = time.Second
+	}
+	if retry.MaxBackoff <= 0 {
+		retry.MaxBackoff = time.Minute
+	}
+	return retry
+}
+
+// openapiPathItem documents the request sent to the subscribers, signed
+// requests document the signature header
+func (webhook Webhook) openapiPathItem(components *openapi.Components, signed bool) openapi.PathItem {
+	operation := &openapi.Operation{
+		OperationId: webhook.OperationId,
+		Summary:     webhook.Summary,
+		Description: webhook.Description,
+		Deprecated:  webhook.Deprecated,
+		Parameters: []openapi.Parameter{
+			{
+				Name:        WebhookIdHeader,
+				In:          "header",
+				Description: "Id of the delivery, redeliveries keep the id",
+				Required:    true,
+				Schema:      openapi.Schema{Type: "string"},
+			},
+			{
+				Name:        WebhookTimestampHeader,
+				In:          "header",
+				Description: "Unix time in seconds the attempt was sent at",
+				Required:    true,
+				Schema:      openapi.Schema{Type: "integer"},
+			},
+		},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]openapi.MediaType{
+				"application/json": {
+					Schema:  componentSchema(components, webhook.Payload),
+					Example: webhook.Payload,
+				},
+			},
+		},
+		Responses: openapi.Responses{
+			HTTPStatusCodeResponses: map[string]openapi.Response{},
+		},
+	}
+	if signed {
+		operation.Parameters = append(operation.Parameters, openapi.Parameter{
+			Name:        WebhookSignatureHeader,
+			In:          "header",
+			Description: "HMAC-SHA256 signature of <timestamp>.<id>.<body> with the secret of the subscriber, formatted as sha256=<hex>. Reject deliveries whose timestamp is older than 5 minutes.",
+			Required:    true,
+			Schema:      openapi.Schema{Type: "string"},
+		})
+	}
+	for status, value := range webhook.Responses.Iterate() {
+		response := openapi.Response{
+			Description: http.StatusText(status),
+			Content: map[string]openapi.MediaType{
+				"application/json": {Schema: componentSchema(components, value), Example: value},
+			},
+		}
+		if status == 0 {
+			response.Description = "Default"
+			operation.Responses.Default = response
+			continue
+		}
+		operation.Responses.HTTPStatusCodeResponses[strconv.Itoa(status)] = response
+	}
+	if len(operation.Responses.HTTPStatusCodeResponses) == 0 && operation.Responses.Default.Content == nil {
+		operation.Responses.HTTPStatusCodeResponses[strconv.Itoa(http.StatusOK)] = openapi.Response{
+			Description: "The webhook was received",
+		}
+	}
+	item := openapi.PathItem{}
+	setOperation(&item, webhook.method(), operation)
+	return item
+}
@@ -0,0 +1,111 @@
+package web_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Instantan/web"
+)
+
+func TestWebhookRetriesAndSigns(t *testing.T) {
+	secret := []byte("secret")
+	mu := sync.Mutex{}
+	ids := []string{}
+	bodies := []string{}
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, err := web.VerifyWebhook(r, secret, 0)
+		if err != nil {
+			t.Errorf("expected a valid signature, got %v", err)
+		}
+		ids = append(ids, r.Header.Get(web.WebhookIdHeader))
+		bodies = append(bodies, string(body))
+		if len(ids) < 3 {
+			// an hour would stall the delivery without the MaxBackoff
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer subscriber.Close()
+
+	webhook := web.Webhook{
+		Name:    "itemCreated",
+		Payload: item{},
+		Retry:   web.Retry{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := webhook.Send(ctx, subscriber.URL, secret, item{Name: "a"}); err != nil {
+		t.Fatalf("expected the third attempt to succeed, got %v", err)
+	}
+	if len(ids) != 3 || ids[0] == "" || ids[0] != ids[1] || ids[1] != ids[2] {
+		t.Errorf("expected three attempts with the same id, got %v", ids)
+	}
+	if bodies[2] != `{"name":"a"}` {
+		t.Errorf("unexpected body %v", bodies[2])
+	}
+}
+
+func TestWebhookStopsOnClientErrors(t *testing.T) {
+	attempts := 0
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer subscriber.Close()
+
+	webhook := web.Webhook{Name: "itemCreated", Payload: item{}, Retry: web.Retry{Backoff: time.Millisecond}}
+	if err := webhook.Send(context.Background(), subscriber.URL, nil, item{}); err == nil || attempts != 1 {
+		t.Errorf("expected a single failed attempt, got %v after %v attempts", err, attempts)
+	}
+}
+
+func TestVerifyWebhookRejectsReplays(t *testing.T) {
+	secret := []byte("secret")
+	body := `{"name":"a"}`
+	request := func(timestamp int64, id string, signature string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(body))
+		r.Header.Set(web.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+		r.Header.Set(web.WebhookIdHeader, id)
+		r.Header.Set(web.WebhookSignatureHeader, signature)
+		return r
+	}
+	now := time.Now().Unix()
+	old := now - int64((10 * time.Minute).Seconds())
+
+	if _, err := web.VerifyWebhook(request(now, "1", web.WebhookSignature(secret, now, "1", []byte(body))), secret, 0); err != nil {
+		t.Errorf("expected a fresh delivery to be accepted, got %v", err)
+	}
+	if _, err := web.VerifyWebhook(request(old, "1", web.WebhookSignature(secret, old, "1", []byte(body))), secret, 0); err == nil {
+		t.Errorf("expected an old delivery to be rejected")
+	}
+	if _, err := web.VerifyWebhook(request(now, "1", web.WebhookSignature(secret, old, "1", []byte(body))), secret, 0); err == nil {
+		t.Errorf("expected a signature of another timestamp to be rejected")
+	}
+	if _, err := web.VerifyWebhook(request(now, "2", web.WebhookSignature(secret, now, "1", []byte(body))), secret, 0); err == nil {
+		t.Errorf("expected a signature of another id to be rejected")
+	}
+}
+
+func TestWebhookIsDocumented(t *testing.T) {
+	w := newWeb()
+	w.Webhook(web.Webhook{Name: "itemCreated", Payload: item{}})
+	operation := lookup(spec(t, w), "webhooks", "itemCreated", "post").(map[string]any)
+	headers := []string{}
+	for _, parameter := range operation["parameters"].([]any) {
+		headers = append(headers, parameter.(map[string]any)["name"].(string))
+		if parameter.(map[string]any)["required"] != true {
+			t.Errorf("expected %v to be required", parameter.(map[string]any)["name"])
+		}
+	}
+	if strings.Join(headers, ",") != "X-Webhook-Id,X-Webhook-Timestamp,X-Webhook-Signature" {
+		t.Errorf("unexpected headers %v", headers)
+	}
+}